@@ -2,14 +2,23 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/cuongceg/validate_yaml/internal/admin"
 	"github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/dedup"
+	"github.com/cuongceg/validate_yaml/internal/metrics"
 	"github.com/cuongceg/validate_yaml/internal/router"
+	"github.com/cuongceg/validate_yaml/internal/tracing"
 	util "github.com/cuongceg/validate_yaml/internal/util"
 	"github.com/cuongceg/validate_yaml/proto/pb"
 	"github.com/redis/go-redis/v9"
@@ -18,7 +27,17 @@ import (
 func main() {
 	var path string
 	var showExample bool
+	var redisAddr string
+	var metricsAddr string
+	var adminAddr string
+	var otlpEndpoint string
+	var otlpInsecure bool
 	flag.StringVar(&path, "config", "configs/config.example.yaml", "Đường dẫn file cấu hình YAML")
+	flag.StringVar(&redisAddr, "redis", "", "Địa chỉ Redis cho dedup, vd 127.0.0.1:6379 (rỗng: dùng store in-memory)")
+	flag.StringVar(&metricsAddr, "metrics", "", "Địa chỉ HTTP phục vụ /metrics, vd 127.0.0.1:9464 (rỗng: tắt)")
+	flag.StringVar(&adminAddr, "admin", "", "Địa chỉ HTTP cho /healthz, /readyz, /routes, /connectors, vd 127.0.0.1:8080 (rỗng: tắt)")
+	flag.StringVar(&otlpEndpoint, "otlp", "", "Endpoint OTLP/HTTP cho tracing, vd localhost:4318 (rỗng: tắt)")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false, "Gửi OTLP qua HTTP không TLS")
 	flag.BoolVar(&showExample, "example", false, "Hiển thị cấu hình mẫu và thoát (không kiểm tra)")
 	flag.Parse()
 
@@ -35,6 +54,25 @@ func main() {
 		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
 		os.Exit(1)
 	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	sum := sha256.Sum256(raw)
+	configHash := hex.EncodeToString(sum[:])
+
+	for _, w := range config.Warnings(userCfg) {
+		util.App.Printf("⚠️  %s", w)
+	}
+
+	envelope := router.NewProtoCodec[*pb.Envelope]()
+	router.RegisterCodec("envelope", func(config.Codec) (router.PayloadCodec, error) { return envelope, nil })
+	codecs, err := router.BuildCodecs(userCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ build codecs: %v\n", err)
+		os.Exit(1)
+	}
 
 	connectors, err := util.MapConnectors(userCfg)
 	if err != nil {
@@ -52,6 +90,47 @@ func main() {
 
 	util.App.Println("✅ Valid configuration & connectors created.")
 
+	connTypes := make(map[string]string, len(userCfg.Connectors))
+	for _, c := range userCfg.Connectors {
+		connTypes[c.Name] = c.Type
+	}
+
+	// metrics: bọc ingress/egress bằng decorator đo metric
+	var reg *metrics.Registry
+	if metricsAddr != "" {
+		reg = metrics.New()
+		for name, c := range connectors {
+			connectors[name] = reg.Wrap(c)
+		}
+	}
+
+	// admin API: đếm theo connector, trạng thái kết nối/route
+	var adm *admin.Server
+	if adminAddr != "" {
+		adm = admin.New(admin.Options{ConfigHash: configHash})
+		for name, c := range connectors {
+			connectors[name] = adm.Wrap(c, connTypes[name])
+		}
+	}
+
+	// tracing: extract/inject traceparent ở mọi ingress/egress, export qua OTLP
+	if otlpEndpoint != "" {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Config{Endpoint: otlpEndpoint, Insecure: otlpInsecure, ServiceName: "validate_yaml-bridge"})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ tracing: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = shutdown(ctx)
+		}()
+		for name, c := range connectors {
+			connectors[name] = tracing.Wrap(c, connTypes[name])
+		}
+		util.App.Printf("🔭 tracing to %s", otlpEndpoint)
+	}
+
 	buses := make(map[string]router.Bus, len(connectors))
 	for name, c := range connectors {
 		b, err := router.NewBusFromConnector(c)
@@ -62,13 +141,35 @@ func main() {
 		buses[name] = b
 	}
 
-	codecs := router.NewProtoCodec[*pb.Envelope]()
+	filters, err := router.CompileFilters(userCfg.Filters)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ compile filters: %v\n", err)
+		os.Exit(1)
+	}
+	projections, err := router.CompileProjections(userCfg.Projections)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ compile projections: %v\n", err)
+		os.Exit(1)
+	}
+	transforms, err := router.CompileTransforms(userCfg.Transforms)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ compile transforms: %v\n", err)
+		os.Exit(1)
+	}
+	schemas, err := router.CompileSchemas(userCfg.Schemas)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ compile schemas: %v\n", err)
+		os.Exit(1)
+	}
 
 	eng := &router.Engine{
 		Buses:          buses,
-		CodecsBySource: codecs,
-		Filters:        router.BuiltinFilters(),
-		Projections:    router.BuiltinProjections(),
+		Codecs:         codecs,
+		DefaultCodec:   envelope,
+		Filters:        filters,
+		Projections:    projections,
+		Schemas:        schemas,
+		Transforms:     transforms,
 		LanesPerTarget: 16,
 		LaneBuffer:     20000,
 	}
@@ -76,19 +177,53 @@ func main() {
 	ctx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stopSignals()
 
-	// Connect to Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:         "127.0.0.1:6379",
-		Password:     "", // nếu có requirepass thì đặt ở đây
-		DB:           0,
-		MinIdleConns: 4,
-		PoolSize:     32,
-	})
+	// HTTP: /metrics và admin API (cùng một server nếu cùng địa chỉ)
+	muxes := map[string]*http.ServeMux{}
+	muxFor := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+		return muxes[addr]
+	}
+	if reg != nil {
+		muxFor(metricsAddr).Handle("GET /metrics", reg.Handler())
+		util.App.Printf("📈 metrics on http://%s/metrics", metricsAddr)
+	}
+	if adm != nil {
+		adm.Register(muxFor(adminAddr))
+		go adm.Run(ctx)
+		util.App.Printf("🩺 admin API on http://%s (/healthz /readyz /routes /connectors)", adminAddr)
+	}
+	for addr, mux := range muxes {
+		srv := &http.Server{Addr: addr, Handler: mux}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				util.App.Printf("❌ http server %s: %v", addr, err)
+			}
+		}()
+		defer srv.Close()
+	}
 
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		panic(err)
+	// Connect to Redis (dedup store); không có Redis -> in-memory, chỉ đúng khi chạy một instance
+	var rdb *redis.Client
+	if redisAddr != "" {
+		rdb = redis.NewClient(&redis.Options{
+			Addr:         redisAddr,
+			Password:     "", // nếu có requirepass thì đặt ở đây
+			DB:           0,
+			MinIdleConns: 4,
+			PoolSize:     32,
+		})
+		if err := rdb.Ping(ctx).Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ redis %s: %v\n", redisAddr, err)
+			os.Exit(1)
+		}
+		defer rdb.Close()
+		util.App.Println("Connected to Redis")
+	} else {
+		eng.Dedup = dedup.NewMemoryStore()
+		util.App.Println("⚠️  Redis disabled (-redis): dedup uses in-memory store, only correct with a single instance")
 	}
-	util.App.Println("Connected to Redis")
 
 	stop, err := eng.StartRoutes(ctx, userCfg, rdb)
 	if err != nil {
@@ -96,12 +231,22 @@ func main() {
 		os.Exit(1)
 	}
 	defer stop()
+	if reg != nil {
+		reg.WatchRoutes(eng)
+	}
+	if adm != nil {
+		adm.WatchRoutes(eng)
+	}
 
 	fmt.Println("🚚 Routes running… Press Ctrl+C to stop.")
 
 	// ✅ Chờ context bị hủy bởi tín hiệu
 	<-ctx.Done()
 	fmt.Println("signal received, shutting down…")
+	for name, st := range eng.Stats() {
+		util.App.Printf("[route=%s] received=%d delivered=%d filtered=%d rejected=%d duplicates=%d looped=%d dropped=%d dead_lettered=%d failed=%d dedup_hits=%d dedup_misses=%d",
+			name, st.Received, st.Delivered, st.Filtered, st.Rejected, st.Duplicates, st.Looped, st.Dropped, st.DeadLettered, st.Failed, st.DedupHits, st.DedupMisses)
+	}
 }
 
 func sampleConfig() string {
@@ -0,0 +1,31 @@
+// dashboard sinh dashboard Grafana cho metric của bridge (cùng định nghĩa với /metrics).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cuongceg/validate_yaml/internal/metrics"
+)
+
+func main() {
+	var out string
+	flag.StringVar(&out, "o", "", "File JSON đầu ra (mặc định: stdout)")
+	flag.Parse()
+
+	b, err := metrics.Dashboard()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	b = append(b, '\n')
+	if out == "" {
+		os.Stdout.Write(b)
+		return
+	}
+	if err := os.WriteFile(out, b, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
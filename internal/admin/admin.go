@@ -0,0 +1,319 @@
+// Package admin là HTTP API quản trị của bridge: /healthz, /readyz, /routes, /connectors.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/router"
+)
+
+// RouteSource: trạng thái route (router.Engine).
+type RouteSource interface {
+	Routes() []router.RouteStatus
+	Ready() (bool, []string)
+}
+
+type Options struct {
+	ConfigHash  string
+	SampleEvery time.Duration // chu kỳ tính throughput (mặc định 5s)
+	FailWindow  time.Duration // connector có lỗi (HealthState.Fail) trong khoảng này thì not ready (mặc định 30s)
+}
+
+// Server giữ trạng thái connector/route; cài core.Observer để đếm theo connector.
+type Server struct {
+	opts    Options
+	started time.Time
+
+	mu     sync.RWMutex
+	conns  []*connState // theo thứ tự Wrap
+	byName map[string]*connState
+	routes RouteSource
+	rates  map[string]rate // route -> throughput lần lấy mẫu gần nhất
+}
+
+type connState struct {
+	name, typ string
+	conn      core.Connector
+	ingress   []string
+	egress    []string
+
+	received, failed, published, publishFailed atomic.Uint64
+
+	mu        sync.Mutex
+	lastErr   string
+	lastErrAt time.Time
+	rate      rate
+}
+
+type rate struct {
+	In  float64 `json:"in_per_sec"`
+	Out float64 `json:"out_per_sec"`
+
+	prevIn, prevOut uint64
+}
+
+func New(opts Options) *Server {
+	if opts.SampleEvery <= 0 {
+		opts.SampleEvery = 5 * time.Second
+	}
+	if opts.FailWindow <= 0 {
+		opts.FailWindow = 30 * time.Second
+	}
+	return &Server{opts: opts, started: time.Now(), byName: map[string]*connState{}, rates: map[string]rate{}}
+}
+
+// Wrap ghi nhận connector và bọc ingress/egress để đếm message theo connector.
+func (s *Server) Wrap(c core.Connector, typ string) core.Connector {
+	cs := &connState{name: c.Name(), typ: typ, conn: c}
+	for _, in := range c.Ingresses() {
+		cs.ingress = append(cs.ingress, in.SourceName())
+	}
+	for _, eg := range c.Egresses() {
+		cs.egress = append(cs.egress, eg.TargetName())
+	}
+	s.mu.Lock()
+	s.conns = append(s.conns, cs)
+	s.byName[cs.name] = cs
+	s.mu.Unlock()
+	return core.WithObserver(c, s)
+}
+
+// WatchRoutes gắn engine sau khi StartRoutes.
+func (s *Server) WatchRoutes(src RouteSource) {
+	s.mu.Lock()
+	s.routes = src
+	s.mu.Unlock()
+}
+
+// ====== core.Observer ======
+
+func (s *Server) conn(name string) *connState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.byName[name]
+}
+
+func (s *Server) Received(connector, _ string) {
+	if cs := s.conn(connector); cs != nil {
+		cs.received.Add(1)
+	}
+}
+
+func (s *Server) Handled(connector, source string, _ time.Duration, err error) {
+	if cs := s.conn(connector); cs != nil && !core.Acked(err) {
+		cs.failed.Add(1)
+		cs.setError(fmt.Errorf("ingress %s: %w", source, err))
+	}
+}
+
+func (s *Server) Published(connector, target string, _ time.Duration, err error) {
+	cs := s.conn(connector)
+	if cs == nil {
+		return
+	}
+	if err != nil {
+		cs.publishFailed.Add(1)
+		cs.setError(fmt.Errorf("egress %s: %w", target, err))
+		return
+	}
+	cs.published.Add(1)
+}
+
+func (cs *connState) setError(err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.lastErr, cs.lastErrAt = err.Error(), time.Now()
+}
+
+// Run lấy mẫu counters định kỳ để tính throughput; dừng khi ctx bị hủy.
+func (s *Server) Run(ctx context.Context) {
+	t := time.NewTicker(s.opts.SampleEvery)
+	defer t.Stop()
+	secs := s.opts.SampleEvery.Seconds()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		s.mu.Lock()
+		for _, cs := range s.conns {
+			in, out := cs.received.Load(), cs.published.Load()
+			cs.mu.Lock()
+			cs.rate = rate{In: float64(in-cs.rate.prevIn) / secs, Out: float64(out-cs.rate.prevOut) / secs, prevIn: in, prevOut: out}
+			cs.mu.Unlock()
+		}
+		if s.routes != nil {
+			for _, rs := range s.routes.Routes() {
+				prev := s.rates[rs.Name]
+				in, out := rs.Stats.Received, rs.Stats.Published
+				s.rates[rs.Name] = rate{In: float64(in-prev.prevIn) / secs, Out: float64(out-prev.prevOut) / secs, prevIn: in, prevOut: out}
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Handler: mux với /healthz, /readyz, /routes, /connectors.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	s.Register(mux)
+	return mux
+}
+
+// Register gắn các endpoint vào mux có sẵn (dùng chung server với /metrics).
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /readyz", s.readyz)
+	mux.HandleFunc("GET /routes", s.listRoutes)
+	mux.HandleFunc("GET /connectors", s.listConnectors)
+}
+
+// ====== handlers ======
+
+func (s *Server) healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":      "ok",
+		"uptime":      time.Since(s.started).Round(time.Second).String(),
+		"config_hash": s.opts.ConfigHash,
+	})
+}
+
+// readyz: mọi connector đang kết nối, không lỗi gần đây, và mọi route đã subscribe nguồn.
+func (s *Server) readyz(w http.ResponseWriter, _ *http.Request) {
+	ready, reasons := s.ready()
+	code, status := http.StatusOK, "ready"
+	if !ready {
+		code, status = http.StatusServiceUnavailable, "not ready"
+	}
+	writeJSON(w, code, map[string]any{"status": status, "reasons": reasons, "config_hash": s.opts.ConfigHash})
+}
+
+func (s *Server) ready() (bool, []string) {
+	s.mu.RLock()
+	conns, routes := s.conns, s.routes
+	s.mu.RUnlock()
+
+	var reasons []string
+	for _, cs := range conns {
+		h, ok := core.HealthOf(cs.conn)
+		switch {
+		case !ok:
+		case !h.Connected:
+			r := fmt.Sprintf("connector %s is disconnected", cs.name)
+			if h.LastError != "" {
+				r += ": " + h.LastError
+			}
+			reasons = append(reasons, r)
+		case h.LastErrorAt.After(h.Since) && time.Since(h.LastErrorAt) < s.opts.FailWindow:
+			// vẫn kết nối nhưng fetch/publish đang lỗi (vd broker Kafka mất)
+			reasons = append(reasons, fmt.Sprintf("connector %s is failing: %s", cs.name, h.LastError))
+		}
+	}
+	if routes == nil {
+		reasons = append(reasons, "routes not started")
+	} else if ok, rs := routes.Ready(); !ok {
+		reasons = append(reasons, rs...)
+	}
+	return len(reasons) == 0, reasons
+}
+
+type routeView struct {
+	router.RouteStatus
+	Throughput rate `json:"throughput"`
+}
+
+func (s *Server) listRoutes(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	src := s.routes
+	rates := make(map[string]rate, len(s.rates))
+	for k, v := range s.rates {
+		rates[k] = v
+	}
+	s.mu.RUnlock()
+
+	out := []routeView{}
+	if src != nil {
+		for _, rs := range src.Routes() {
+			out = append(out, routeView{RouteStatus: rs, Throughput: rates[rs.Name]})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"config_hash": s.opts.ConfigHash, "routes": out})
+}
+
+type connectorView struct {
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	State         string     `json:"state"` // connected | disconnected | unknown
+	Since         *time.Time `json:"since,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	Ingress       []string   `json:"ingress"`
+	Egress        []string   `json:"egress"`
+	Received      uint64     `json:"received"`
+	Failed        uint64     `json:"failed"`
+	Published     uint64     `json:"published"`
+	PublishFailed uint64     `json:"publish_failed"`
+	Throughput    rate       `json:"throughput"`
+}
+
+func (s *Server) listConnectors(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	conns := append([]*connState(nil), s.conns...)
+	s.mu.RUnlock()
+	sort.SliceStable(conns, func(i, j int) bool { return conns[i].name < conns[j].name })
+
+	out := make([]connectorView, 0, len(conns))
+	for _, cs := range conns {
+		v := connectorView{
+			Name:          cs.name,
+			Type:          cs.typ,
+			State:         "unknown",
+			Ingress:       cs.ingress,
+			Egress:        cs.egress,
+			Received:      cs.received.Load(),
+			Failed:        cs.failed.Load(),
+			Published:     cs.published.Load(),
+			PublishFailed: cs.publishFailed.Load(),
+		}
+		lastErr, lastAt := "", time.Time{}
+		if h, ok := core.HealthOf(cs.conn); ok {
+			v.State = "disconnected"
+			if h.Connected {
+				v.State = "connected"
+			}
+			if !h.Since.IsZero() {
+				since := h.Since
+				v.Since = &since
+			}
+			lastErr, lastAt = h.LastError, h.LastErrorAt
+		}
+		cs.mu.Lock()
+		if cs.lastErrAt.After(lastAt) {
+			lastErr, lastAt = cs.lastErr, cs.lastErrAt
+		}
+		v.Throughput = cs.rate
+		cs.mu.Unlock()
+		if lastErr != "" {
+			v.LastError, v.LastErrorAt = lastErr, &lastAt
+		}
+		out = append(out, v)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"config_hash": s.opts.ConfigHash, "connectors": out})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/router"
+)
+
+// fakeConn: connector không ingress/egress, báo health qua core.HealthState.
+type fakeConn struct {
+	name   string
+	health core.HealthState
+}
+
+func (c *fakeConn) Name() string              { return c.name }
+func (c *fakeConn) Open() error               { return nil }
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Ingresses() []core.Ingress { return nil }
+func (c *fakeConn) Egresses() []core.Egress   { return nil }
+func (c *fakeConn) Health() core.Health       { return c.health.Health() }
+
+// fakeRoutes: RouteSource cố định.
+type fakeRoutes struct {
+	ready   bool
+	reasons []string
+}
+
+func (r fakeRoutes) Routes() []router.RouteStatus { return nil }
+func (r fakeRoutes) Ready() (bool, []string)      { return r.ready, r.reasons }
+
+type readyResp struct {
+	Status  string   `json:"status"`
+	Reasons []string `json:"reasons"`
+}
+
+func getReady(t *testing.T, h http.Handler) (int, readyResp) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body readyResp
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode /readyz: %v (%s)", err, rec.Body)
+	}
+	return rec.Code, body
+}
+
+func wantReady(t *testing.T, h http.Handler, ready bool, reason string) {
+	t.Helper()
+	code, body := getReady(t, h)
+	if ready {
+		if code != http.StatusOK || body.Status != "ready" {
+			t.Fatalf("/readyz = %d %+v, want 200 ready", code, body)
+		}
+		return
+	}
+	if code != http.StatusServiceUnavailable || !strings.Contains(strings.Join(body.Reasons, "; "), reason) {
+		t.Fatalf("/readyz = %d %+v, want 503 with reason %q", code, body, reason)
+	}
+}
+
+func TestReadyzConnectorHealth(t *testing.T) {
+	s := New(Options{FailWindow: 50 * time.Millisecond})
+	c := &fakeConn{name: "kafka_01"}
+	s.Wrap(c, "kafka")
+	s.WatchRoutes(fakeRoutes{ready: true})
+	h := s.Handler()
+
+	c.health.Down(errors.New("dial tcp: connection refused"))
+	wantReady(t, h, false, "connector kafka_01 is disconnected: dial tcp: connection refused")
+
+	c.health.Up()
+	wantReady(t, h, true, "")
+
+	// vẫn kết nối nhưng fetch lỗi: not ready trong FailWindow, rồi ready lại
+	c.health.Fail(errors.New("fetch orders: broker not available"))
+	wantReady(t, h, false, "connector kafka_01 is failing: fetch orders: broker not available")
+	time.Sleep(80 * time.Millisecond)
+	wantReady(t, h, true, "")
+}
+
+// lỗi trước lần reconnect gần nhất không làm not ready.
+func TestReadyzFailBeforeReconnect(t *testing.T) {
+	s := New(Options{})
+	c := &fakeConn{name: "nats_01"}
+	s.Wrap(c, "nats")
+	s.WatchRoutes(fakeRoutes{ready: true})
+
+	c.health.Fail(errors.New("publish: timeout"))
+	time.Sleep(time.Millisecond)
+	c.health.Up()
+	wantReady(t, s.Handler(), true, "")
+}
+
+func TestReadyzRoutes(t *testing.T) {
+	s := New(Options{})
+	h := s.Handler()
+	wantReady(t, h, false, "routes not started")
+
+	s.WatchRoutes(fakeRoutes{reasons: []string{"route r is failed"}})
+	wantReady(t, h, false, "route r is failed")
+}
+
+// gateBus: Subscribe chỉ trả về khi gate đóng, route ở trạng thái starting tới lúc đó.
+type gateBus struct{ gate chan struct{} }
+
+func (b *gateBus) Subscribe(ctx context.Context, _ string, _ func(context.Context, *router.Message) error) error {
+	select {
+	case <-b.gate:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+func (b *gateBus) Publish(context.Context, string, *router.Message) error { return nil }
+func (b *gateBus) Close() error                                           { return nil }
+
+func TestReadyzRouteNotRunning(t *testing.T) {
+	bus := &gateBus{gate: make(chan struct{})}
+	eng := &router.Engine{Buses: map[string]router.Bus{"bus": bus}, DefaultCodec: router.NewJSONCodec()}
+	stop, err := eng.StartRoutes(context.Background(), &config.UserConfig{Routes: []config.Route{{
+		Name: "r",
+		From: config.RouteStartpoint{Connector: "bus", Source: "in"},
+		To:   &config.RouteEndpoint{Connector: "bus", Target: "out"},
+		Mode: config.RouteMode{Type: "drop"},
+	}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	s := New(Options{})
+	s.WatchRoutes(eng)
+	h := s.Handler()
+	wantReady(t, h, false, "route r is starting")
+
+	close(bus.gate)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if code, _ := getReady(t, h); code == http.StatusOK {
+			break
+		}
+		if time.Now().After(deadline) {
+			wantReady(t, h, true, "")
+		}
+	}
+}
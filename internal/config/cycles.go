@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cuongceg/validate_yaml/internal/expr"
+)
+
+// routeCycles tìm các vòng route -> target -> ingress của route khác -> ... quay lại.
+// Route A nối tới route B khi một target của A publish vào đúng chỗ ingress của B đọc
+// (cùng connector): kafka topic, nats subject (có wildcard), rabbitmq queue qua
+// default exchange. definite: mọi cạnh đều khớp chắc chắn (template không có
+// placeholder); possible: có cạnh dựa trên template có placeholder.
+func routeCycles(cfg *UserConfig) (definite, possible [][]string) {
+	type edge struct {
+		to    int
+		exact bool
+	}
+	connByName := map[string]*Connector{}
+	for i := range cfg.Connectors {
+		connByName[cfg.Connectors[i].Name] = &cfg.Connectors[i]
+	}
+	groups := map[string][]RouteEndpoint{}
+	for _, g := range cfg.GroupReceivers {
+		groups[g.Name] = g.Targets
+	}
+
+	adj := make([][]edge, len(cfg.Routes))
+	for a, ra := range cfg.Routes {
+		for _, tgt := range routeTargets(ra, groups) {
+			for b, rb := range cfg.Routes {
+				if tgt.Connector != rb.From.Connector {
+					continue
+				}
+				if feeds, exact := egressFeeds(connByName[tgt.Connector], tgt.Target, rb.From.Source); feeds {
+					adj[a] = append(adj[a], edge{to: b, exact: exact})
+				}
+			}
+		}
+	}
+
+	// DFS: mỗi back-edge là một vòng; chuẩn hóa (xoay về route nhỏ nhất) để không lặp
+	seen := map[string]bool{}
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := make([]int, len(cfg.Routes))
+	var stack []int
+	var stackExact []bool // stackExact[i]: cạnh stack[i] -> stack[i+1] (hoặc back-edge) khớp chắc chắn
+	var visit func(u int)
+	visit = func(u int) {
+		color[u] = grey
+		stack = append(stack, u)
+		for _, e := range adj[u] {
+			stackExact = append(stackExact, e.exact)
+			switch color[e.to] {
+			case white:
+				visit(e.to)
+			case grey:
+				start := 0
+				for i, n := range stack {
+					if n == e.to {
+						start = i
+						break
+					}
+				}
+				cyc := append([]int(nil), stack[start:]...)
+				exact := true
+				for _, x := range stackExact[start:] {
+					exact = exact && x
+				}
+				min := 0
+				for i := range cyc {
+					if cyc[i] < cyc[min] {
+						min = i
+					}
+				}
+				names := make([]string, 0, len(cyc)+1)
+				for i := range cyc {
+					names = append(names, cfg.Routes[cyc[(min+i)%len(cyc)]].Name)
+				}
+				names = append(names, names[0])
+				key := strings.Join(names, "\x00")
+				if !seen[key] {
+					seen[key] = true
+					if exact {
+						definite = append(definite, names)
+					} else {
+						possible = append(possible, names)
+					}
+				}
+			}
+			stackExact = stackExact[:len(stackExact)-1]
+		}
+		stack = stack[:len(stack)-1]
+		color[u] = black
+	}
+	for u := range cfg.Routes {
+		if color[u] == white {
+			visit(u)
+		}
+	}
+	sort.Slice(definite, func(i, j int) bool { return strings.Join(definite[i], ",") < strings.Join(definite[j], ",") })
+	sort.Slice(possible, func(i, j int) bool { return strings.Join(possible[i], ",") < strings.Join(possible[j], ",") })
+	return definite, possible
+}
+
+// routeTargets: mọi đích giao hàng của route (to, to_group, các case của switch).
+func routeTargets(r Route, groups map[string][]RouteEndpoint) []RouteEndpoint {
+	var out []RouteEndpoint
+	add := func(to *RouteEndpoint, toGroup string) {
+		if to != nil && to.Connector != "" {
+			out = append(out, *to)
+		}
+		if toGroup != "" {
+			out = append(out, groups[toGroup]...)
+		}
+	}
+	if r.Switch != nil {
+		for _, c := range r.Switch.Cases {
+			add(c.To, c.ToGroup)
+		}
+		if d := r.Switch.Default; d != nil {
+			add(d.To, d.ToGroup)
+		}
+		return out
+	}
+	add(r.To, r.ToGroup)
+	return out
+}
+
+// egressFeeds: egress target của connector c có publish vào ingress source của cùng connector.
+func egressFeeds(c *Connector, target, source string) (feeds, exact bool) {
+	if c == nil {
+		return false, false
+	}
+	var eg *Egress
+	for i := range c.Egress {
+		if c.Egress[i].Name == target {
+			eg = &c.Egress[i]
+		}
+	}
+	var in *Ingress
+	for i := range c.Ingress {
+		if c.Ingress[i].SourceName == source {
+			in = &c.Ingress[i]
+		}
+	}
+	if eg == nil || in == nil {
+		return false, false
+	}
+
+	var tplSrc, addr string
+	switch strings.ToLower(c.Type) {
+	case "kafka":
+		tplSrc, addr = eg.TopicTemplate, in.Topic
+	case "nats":
+		tplSrc, addr = eg.SubjectTemplate, in.Subject
+	case "rabbitmq":
+		// exchange có tên: binding nằm ở broker, không biết được từ config
+		if eg.Exchange != "" {
+			return false, false
+		}
+		tplSrc, addr = eg.RoutingKeyTemplate, in.Queue
+	default:
+		return false, false
+	}
+	if tplSrc == "" || addr == "" {
+		return false, false
+	}
+	tpl, err := expr.ParseTemplate(tplSrc)
+	if err != nil {
+		return false, false
+	}
+	wildcard := strings.ContainsAny(addr, "*>") && strings.ToLower(c.Type) == "nats"
+	switch {
+	case tpl.Static() && wildcard:
+		return subjectMatches(addr, tplSrc), true
+	case tpl.Static():
+		return tplSrc == addr, true
+	case !wildcard:
+		return tpl.Matches(addr), false
+	}
+	return false, false // placeholder + wildcard: không xác định được
+}
+
+// subjectMatches: subject NATS khớp pattern (* một token, > phần còn lại).
+func subjectMatches(pattern, subject string) bool {
+	pt := strings.Split(pattern, ".")
+	st := strings.Split(subject, ".")
+	for i, p := range pt {
+		if p == ">" {
+			return len(st) > i
+		}
+		if i >= len(st) || (p != "*" && p != st[i]) {
+			return false
+		}
+	}
+	return len(pt) == len(st)
+}
+
+func formatCycle(names []string) string {
+	return fmt.Sprintf("[%s]", strings.Join(names, " -> "))
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func cycleConfig() *UserConfig {
+	return &UserConfig{
+		Connectors: []Connector{
+			{
+				Name: "k", Type: "kafka",
+				Ingress: []Ingress{{SourceName: "a-in", Topic: "a"}, {SourceName: "b-in", Topic: "b"}, {SourceName: "c-in", Topic: "c.eu"}},
+				Egress: []Egress{
+					{Name: "to-a", TopicTemplate: "a"},
+					{Name: "to-b", TopicTemplate: "b"},
+					{Name: "to-c", TopicTemplate: "c.{payload.region}"},
+				},
+			},
+			{
+				Name: "n", Type: "nats",
+				Ingress: []Ingress{{SourceName: "orders", Subject: "orders.>"}},
+				Egress:  []Egress{{Name: "echo", SubjectTemplate: "orders.echo"}},
+			},
+		},
+	}
+}
+
+func route(name, fromConn, fromSrc, toConn, toTarget string) Route {
+	return Route{
+		Name: name,
+		From: RouteStartpoint{Connector: fromConn, Source: fromSrc},
+		To:   &RouteEndpoint{Connector: toConn, Target: toTarget},
+	}
+}
+
+func TestRouteCyclesDefinite(t *testing.T) {
+	cfg := cycleConfig()
+	cfg.Routes = []Route{
+		route("ab", "k", "a-in", "k", "to-b"),
+		route("ba", "k", "b-in", "k", "to-a"),
+		route("self", "n", "orders", "n", "echo"), // orders.echo khớp orders.>
+	}
+	definite, possible := routeCycles(cfg)
+	want := [][]string{{"ab", "ba", "ab"}, {"self", "self"}}
+	if !reflect.DeepEqual(definite, want) {
+		t.Fatalf("definite = %v, want %v", definite, want)
+	}
+	if len(possible) != 0 {
+		t.Fatalf("possible = %v, want none", possible)
+	}
+	if err := validateRouteCycles(cfg); err == nil || !strings.Contains(err.Error(), "[ab -> ba -> ab]") {
+		t.Fatalf("validateRouteCycles = %v", err)
+	}
+}
+
+func TestRouteCyclesPossibleThroughTemplate(t *testing.T) {
+	cfg := cycleConfig()
+	cfg.Routes = []Route{
+		route("bc", "k", "b-in", "k", "to-c"), // c.{payload.region} có thể là c.eu
+		route("cb", "k", "c-in", "k", "to-b"),
+	}
+	definite, possible := routeCycles(cfg)
+	if len(definite) != 0 {
+		t.Fatalf("definite = %v, want none", definite)
+	}
+	if want := [][]string{{"bc", "cb", "bc"}}; !reflect.DeepEqual(possible, want) {
+		t.Fatalf("possible = %v, want %v", possible, want)
+	}
+	if err := validateRouteCycles(cfg); err != nil {
+		t.Fatalf("templated loop must not fail validation: %v", err)
+	}
+	warns := Warnings(cfg)
+	if len(warns) != 1 || !strings.Contains(warns[0], "[bc -> cb -> bc]") {
+		t.Fatalf("Warnings = %v", warns)
+	}
+}
+
+func TestRouteCyclesNone(t *testing.T) {
+	cfg := cycleConfig()
+	cfg.Routes = []Route{
+		route("ab", "k", "a-in", "k", "to-b"),
+		route("b-out", "k", "b-in", "n", "echo"), // sang connector khác: không có cạnh về k
+	}
+	if definite, possible := routeCycles(cfg); len(definite)+len(possible) != 0 {
+		t.Fatalf("cycles = %v / %v, want none", definite, possible)
+	}
+}
+
+func TestSubjectMatches(t *testing.T) {
+	tests := []struct {
+		pattern, subject string
+		want             bool
+	}{
+		{"orders.*", "orders.eu", true},
+		{"orders.*", "orders.eu.x", false},
+		{"orders.>", "orders.eu.x", true},
+		{"orders.>", "orders", false},
+		{"orders.eu", "orders.eu", true},
+		{"*.eu", "orders.us", false},
+	}
+	for _, tt := range tests {
+		if got := subjectMatches(tt.pattern, tt.subject); got != tt.want {
+			t.Errorf("subjectMatches(%q, %q) = %v, want %v", tt.pattern, tt.subject, got, tt.want)
+		}
+	}
+}
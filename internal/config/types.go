@@ -6,6 +6,26 @@ type UserConfig struct {
 	Projections    []ProjectionRule `yaml:"projections,omitempty"`
 	GroupReceivers []GroupReceiver  `yaml:"group_receivers,omitempty"`
 	Routes         []Route          `yaml:"routes"`
+	Codecs         []Codec          `yaml:"codecs,omitempty"`
+	Schemas        []SchemaRule     `yaml:"schema,omitempty"`
+	Transforms     []TransformRule  `yaml:"transforms,omitempty"`
+}
+
+// SchemaRule: schema kiểm tra payload đã decode; route tham chiếu qua tên.
+type SchemaRule struct {
+	Name          string   `yaml:"name"`
+	Type          string   `yaml:"type"`                     // json_schema | protobuf
+	File          string   `yaml:"file,omitempty"`           // json_schema: file JSON Schema
+	DescriptorSet string   `yaml:"descriptor_set,omitempty"` // protobuf: FileDescriptorSet
+	Message       string   `yaml:"message,omitempty"`        // protobuf: full name message
+	Required      []string `yaml:"required,omitempty"`       // protobuf: payload.* bắt buộc (proto3 không có required)
+}
+
+// Codec khai báo codec có tham số; ingress/egress tham chiếu qua tên.
+type Codec struct {
+	Name   string                 `yaml:"name"`
+	Type   string                 `yaml:"type"`
+	Params map[string]interface{} `yaml:"params,omitempty"`
 }
 
 type Connector struct {
@@ -13,6 +33,7 @@ type Connector struct {
 	Type    string                 `yaml:"type"` // kafka|nats|rabbitmq
 	Params  map[string]interface{} `yaml:"params"`
 	TLS     *TLSConfig             `yaml:"tls,omitempty"`
+	SASL    *SASLConfig            `yaml:"sasl,omitempty"` // chỉ kafka
 	Ingress []Ingress              `yaml:"ingress,omitempty"`
 	Egress  []Egress               `yaml:"egress,omitempty"`
 }
@@ -25,12 +46,29 @@ type TLSConfig struct {
 	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
 }
 
+type SASLConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Mechanism string `yaml:"mechanism"` // PLAIN|SCRAM-SHA-256|SCRAM-SHA-512
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
+
 type Ingress struct {
-	Topic      string `yaml:"topic,omitempty"`
-	Queue      string `yaml:"queue,omitempty"`
-	Subject    string `yaml:"subject,omitempty"`
-	GroupID    string `yaml:"group_id,omitempty"`
-	SourceName string `yaml:"source_name"` // tên logic để map route
+	Topic         string            `yaml:"topic,omitempty"`
+	Queue         string            `yaml:"queue,omitempty"`
+	Subject       string            `yaml:"subject,omitempty"`
+	GroupID       string            `yaml:"group_id,omitempty"` // kafka consumer group | nats queue group
+	SourceName    string            `yaml:"source_name"`        // tên logic để map route
+	KeyFrom       string            `yaml:"key_from,omitempty"` // nats: payload.userId | header.X-Device -> meta.route_key
+	HeadersToMeta []string          `yaml:"headers_to_meta,omitempty"`
+	JetStream     *JetStreamIngress `yaml:"jetstream,omitempty"`
+	Codec         string            `yaml:"codec,omitempty"` // tên codec để decode payload
+}
+
+type JetStreamIngress struct {
+	Enabled bool   `yaml:"enabled"`
+	Durable string `yaml:"durable,omitempty"`
+	Pull    bool   `yaml:"pull,omitempty"`
 }
 
 type Egress struct {
@@ -41,6 +79,8 @@ type Egress struct {
 	Exchange           string `yaml:"exchange,omitempty"`
 	Kind               string `yaml:"kind,omitempty"`
 	RoutingKeyTemplate string `yaml:"routing_key_template,omitempty"`
+	JetStream          bool   `yaml:"jetstream,omitempty"` // nats: publish qua JetStream
+	Codec              string `yaml:"codec,omitempty"`     // encode lại payload nếu khác codec nguồn
 }
 
 type FilterRule struct {
@@ -56,6 +96,24 @@ type ProjectionRule struct {
 	OnMissingField string   `yaml:"on_missing_field,omitempty"` // drop|skip|false
 }
 
+// TransformRule: chuỗi thao tác áp dụng theo thứ tự lên payload/meta (sau filters, trước projection/encode).
+type TransformRule struct {
+	Name string        `yaml:"name"`
+	Ops  []TransformOp `yaml:"ops"`
+}
+
+type TransformOp struct {
+	Op       string      `yaml:"op"`                  // rename|copy|set|delete|mask|hash|convert
+	Field    string      `yaml:"field"`               // payload.a.b | meta.x
+	To       string      `yaml:"to,omitempty"`        // rename/copy: path đích (payload <-> meta được)
+	Value    interface{} `yaml:"value,omitempty"`     // set: hằng số
+	Expr     string      `yaml:"expr,omitempty"`      // set: biểu thức CEL
+	Type     string      `yaml:"type,omitempty"`      // convert: string|int|float|bool
+	KeepLast int         `yaml:"keep_last,omitempty"` // mask: số ký tự cuối giữ lại
+	MaskChar string      `yaml:"mask_char,omitempty"` // mask: mặc định "*"
+	Salt     string      `yaml:"salt,omitempty"`      // hash: sha256(salt + value)
+}
+
 type GroupReceiver struct {
 	Name    string          `yaml:"name"`
 	Targets []RouteEndpoint `yaml:"targets"`
@@ -75,13 +133,43 @@ type TargetConfig struct {
 }
 
 type Route struct {
-	Name       string          `yaml:"name"`
-	From       RouteStartpoint `yaml:"from"`
-	To         *RouteEndpoint  `yaml:"to,omitempty"`
-	ToGroup    string          `yaml:"to_group,omitempty"`
-	Mode       RouteMode       `yaml:"mode"`
-	Filters    []string        `yaml:"filters,omitempty"`    // tham chiếu theo tên filter
-	Projection string          `yaml:"projection,omitempty"` // tham chiếu theo tên projection
+	Name         string          `yaml:"name"`
+	From         RouteStartpoint `yaml:"from"`
+	To           *RouteEndpoint  `yaml:"to,omitempty"`
+	ToGroup      string          `yaml:"to_group,omitempty"`
+	Switch       *RouteSwitch    `yaml:"switch,omitempty"` // chọn đích theo từng message (thay cho to/to_group)
+	Mode         RouteMode       `yaml:"mode"`
+	Ordering     string          `yaml:"ordering,omitempty"`      // none (mặc định) | key | strict
+	PartitionKey string          `yaml:"partition_key,omitempty"` // ordering=key: route_key | key | meta.X | payload.a.b
+	Filters      []string        `yaml:"filters,omitempty"`       // tham chiếu theo tên filter
+	Projection   string          `yaml:"projection,omitempty"`    // tham chiếu theo tên projection
+	Codec        string          `yaml:"codec,omitempty"`         // ghi đè codec của ingress cho route này
+	OutputCodec  string          `yaml:"output_codec,omitempty"`  // transcode cho mọi target (ưu tiên hơn egress.codec)
+	Schema       string          `yaml:"schema,omitempty"`        // kiểm tra payload theo schema (tên trong schema:)
+	Transform    string          `yaml:"transform,omitempty"`     // tham chiếu theo tên transform
+	Reject       *RouteEndpoint  `yaml:"reject,omitempty"`        // egress nhận message không hợp lệ schema
+	DeadLetter   *RouteEndpoint  `yaml:"dead_letter,omitempty"`   // egress nhận message lỗi (decode/filter/hết attempts)
+	Dedup        *RouteDedup     `yaml:"dedup,omitempty"`         // bỏ qua message đã giao tới target (theo message ID)
+	MaxHops      int             `yaml:"max_hops,omitempty"`      // số hop tối đa message đã đi qua (mặc định 10)
+}
+
+// RouteDedup: ID message được ghi nhận theo route/target với TTL (Redis hoặc in-memory).
+type RouteDedup struct {
+	IDFrom string `yaml:"id_from,omitempty"` // idempotencyKey (mặc định) | header.X | meta.X | payload.a.b | content_hash
+	TTLms  int64  `yaml:"ttl_ms,omitempty"`  // mặc định 24h
+}
+
+// RouteSwitch: cases xét theo thứ tự, case đầu tiên có when = true thắng;
+// không case nào khớp thì dùng default, không có default thì message bị lọc.
+type RouteSwitch struct {
+	Cases   []RouteCase `yaml:"cases"`
+	Default *RouteCase  `yaml:"default,omitempty"`
+}
+
+type RouteCase struct {
+	When    string         `yaml:"when,omitempty"` // biểu thức CEL (bool) trên payload/meta
+	To      *RouteEndpoint `yaml:"to,omitempty"`
+	ToGroup string         `yaml:"to_group,omitempty"`
 }
 
 type RouteStartpoint struct {
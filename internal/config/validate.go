@@ -3,9 +3,12 @@ package config
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/cuongceg/validate_yaml/internal/expr"
 	"github.com/go-playground/validator/v10"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 )
 
 func ValidateConfig(cfg *UserConfig) error {
@@ -34,9 +37,82 @@ func ValidateConfig(cfg *UserConfig) error {
 		allErrs = append(allErrs, err)
 	}
 
+	// 6) Compile biểu thức CEL của filters
+	if err := validateFilterRules(cfg); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	// 7) Kiểm tra include path của projections
+	if err := validateProjectionRules(cfg); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	// 8) Codecs khai báo
+	if err := validateCodecs(cfg); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	// 9) Schema kiểm tra payload
+	if err := validateSchemaRules(cfg); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	// 10) Transforms
+	if err := validateTransformRules(cfg); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	// 11) Vòng lặp giữa các route (A publish vào nguồn của B, B vào nguồn của A)
+	if err := validateRouteCycles(cfg); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	return joinErrors(allErrs)
 }
 
+// Warnings trả các cấu hình hợp lệ nhưng dễ gây bất ngờ (không chặn khởi động).
+func Warnings(cfg *UserConfig) []string {
+	var warns []string
+
+	// nhiều route chung một nguồn: ingress chỉ ack khi mọi route xong, nên route
+	// persistent (retry mãi) sẽ giữ message của route drop cùng nguồn
+	type srcKey struct{ conn, src string }
+	modes := map[srcKey]map[string][]string{}
+	var order []srcKey
+	for _, r := range cfg.Routes {
+		k := srcKey{r.From.Connector, r.From.Source}
+		if modes[k] == nil {
+			modes[k] = map[string][]string{}
+			order = append(order, k)
+		}
+		m := strings.ToLower(r.Mode.Type)
+		modes[k][m] = append(modes[k][m], r.Name)
+	}
+	for _, k := range order {
+		if len(modes[k]) > 1 {
+			warns = append(warns, fmt.Sprintf("source %s/%s is shared by routes with different modes (persistent=%v, drop=%v): messages are acked only after every route finishes",
+				k.conn, k.src, modes[k]["persistent"], modes[k]["drop"]))
+		}
+	}
+
+	// vòng lặp qua topic/subject có placeholder: có thể xảy ra tùy giá trị lúc chạy
+	_, possible := routeCycles(cfg)
+	for _, c := range possible {
+		warns = append(warns, fmt.Sprintf("routes may form a loop through templated targets %s: messages are dropped at runtime when they revisit a hop", formatCycle(c)))
+	}
+
+	return warns
+}
+
+func validateRouteCycles(cfg *UserConfig) error {
+	var errs []error
+	definite, _ := routeCycles(cfg)
+	for _, c := range definite {
+		errs = append(errs, fmt.Errorf("routes form a loop %s", formatCycle(c)))
+	}
+	return joinErrors(errs)
+}
+
 func validateConnectors(cfg *UserConfig) error {
 	var errs []error
 
@@ -67,6 +143,9 @@ func validateConnectors(cfg *UserConfig) error {
 			if err := requireStringParam(i, &c, "url"); err != nil {
 				errs = append(errs, err)
 			}
+			if err := validateNATSAuth(i, &c); err != nil {
+				errs = append(errs, err)
+			}
 		default:
 			errs = append(errs, fmt.Errorf("connectors[%d]: unsupported type %q (expect: kafka|rabbitmq|nats)", i, c.Type))
 		}
@@ -87,6 +166,21 @@ func validateConnectors(cfg *UserConfig) error {
 			}
 		}
 
+		// SASL: chỉ kafka; enabled thì cần mechanism hợp lệ + username/password
+		if c.SASL != nil && c.SASL.Enabled {
+			if strings.ToLower(c.Type) != "kafka" {
+				errs = append(errs, fmt.Errorf("connectors[%d]: sasl is only supported for kafka connectors", i))
+			}
+			switch strings.ToUpper(c.SASL.Mechanism) {
+			case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+			default:
+				errs = append(errs, fmt.Errorf("connectors[%d]: sasl.mechanism must be PLAIN|SCRAM-SHA-256|SCRAM-SHA-512", i))
+			}
+			if strings.TrimSpace(c.SASL.Username) == "" || c.SASL.Password == "" {
+				errs = append(errs, fmt.Errorf("connectors[%d]: sasl requires username and password", i))
+			}
+		}
+
 		// Ingress/Egress: validate đặt tên nguồn/đích để tham chiếu trong routes
 		ingressNames := make(map[string]struct{})
 		for j, in := range c.Ingress {
@@ -102,6 +196,14 @@ func validateConnectors(cfg *UserConfig) error {
 			if in.Topic == "" && in.Queue == "" && in.Subject == "" {
 				errs = append(errs, fmt.Errorf("connectors[%d].ingress[%d]: require one of topic|queue|subject", i, j))
 			}
+			if strings.ToLower(c.Type) == "nats" {
+				if strings.TrimSpace(in.Subject) == "" {
+					errs = append(errs, fmt.Errorf("connectors[%d].ingress[%d]: nats ingress requires subject", i, j))
+				}
+				if in.JetStream != nil && in.JetStream.Enabled && in.JetStream.Pull && strings.TrimSpace(in.JetStream.Durable) == "" {
+					errs = append(errs, fmt.Errorf("connectors[%d].ingress[%d]: jetstream pull consumer requires durable", i, j))
+				}
+			}
 		}
 
 		egressNames := make(map[string]struct{})
@@ -117,6 +219,14 @@ func validateConnectors(cfg *UserConfig) error {
 				egressNames[getName] = struct{}{}
 			}
 			// Ít nhất phải có một đích hợp lệ theo type
+			for _, tpl := range []string{eg.TopicTemplate, eg.SubjectTemplate, eg.RoutingKeyTemplate} {
+				if tpl == "" {
+					continue
+				}
+				if _, err := expr.ParseTemplate(tpl); err != nil {
+					errs = append(errs, fmt.Errorf("connectors[%d].egress[%d]: %v", i, j, err))
+				}
+			}
 			switch strings.ToLower(eg.Type) {
 			case "topic":
 				if strings.TrimSpace(eg.TopicTemplate) == "" {
@@ -208,6 +318,57 @@ func validateRoutesEndpoints(cfg *UserConfig) error {
 		groupSet[g.Name] = true
 	}
 
+	// checkEgress kiểm tra một endpoint phụ của route (dead_letter, reject, ...) trỏ tới egress có thật
+	checkEgress := func(i int, field string, ep *RouteEndpoint) error {
+		switch {
+		case strings.TrimSpace(ep.Connector) == "":
+			return fmt.Errorf("routes[%d].%s.connector is required", i, field)
+		case connectorMap[ep.Connector] == nil:
+			return fmt.Errorf("routes[%d].%s.connector %q not found", i, field, ep.Connector)
+		case strings.TrimSpace(ep.Target) == "":
+			return fmt.Errorf("routes[%d].%s.target is required (egress name)", i, field)
+		case !egressIdx[egressKey{ep.Connector, ep.Target}]:
+			return fmt.Errorf("routes[%d].%s: target %q not found in connector %q", i, field, ep.Target, ep.Connector)
+		}
+		return nil
+	}
+
+	// checkDest kiểm tra cặp to/to_group của route hoặc của một case trong switch
+	checkDest := func(prefix string, from RouteStartpoint, to *RouteEndpoint, toGroup string) []error {
+		var errs []error
+		hasTo := to != nil && strings.TrimSpace(to.Connector) != ""
+		hasGroup := strings.TrimSpace(toGroup) != ""
+		if !hasTo && !hasGroup {
+			return []error{fmt.Errorf("%s: either 'to' or 'to_group' must be provided", prefix)}
+		}
+		if hasTo && hasGroup {
+			errs = append(errs, fmt.Errorf("%s: cannot set both 'to' and 'to_group'", prefix))
+		}
+		if hasTo {
+			if _, ok := connectorMap[to.Connector]; !ok {
+				errs = append(errs, fmt.Errorf("%s.to.connector %q not found", prefix, to.Connector))
+			} else {
+				if strings.TrimSpace(to.Target) == "" {
+					errs = append(errs, fmt.Errorf("%s.to.target is required (egress name)", prefix))
+				} else {
+					if !egressIdx[egressKey{to.Connector, to.Target}] {
+						errs = append(errs, fmt.Errorf("%s.to: target %q not found in connector %q", prefix, to.Target, to.Connector))
+					}
+
+					if to.Connector == from.Connector && to.Target == from.Source {
+						errs = append(errs, fmt.Errorf("%s: cannot route from and to the same source/target", prefix))
+					}
+				}
+			}
+		}
+		if hasGroup {
+			if !groupSet[toGroup] {
+				errs = append(errs, fmt.Errorf("%s.to_group %q not found", prefix, toGroup))
+			}
+		}
+		return errs
+	}
+
 	for i, r := range cfg.Routes {
 		if strings.TrimSpace(r.From.Connector) == "" {
 			errs = append(errs, fmt.Errorf("routes[%d].from.connector is required", i))
@@ -226,39 +387,86 @@ func validateRoutesEndpoints(cfg *UserConfig) error {
 			}
 		}
 
-		// to hoặc to_group
-		hasTo := r.To != nil && strings.TrimSpace(r.To.Connector) != ""
-		hasGroup := strings.TrimSpace(r.ToGroup) != ""
-		if !hasTo && !hasGroup {
-			errs = append(errs, fmt.Errorf("routes[%d]: either 'to' or 'to_group' must be provided", i))
-			continue
+		// dead_letter / reject (tùy chọn)
+		if r.DeadLetter != nil {
+			if err := checkEgress(i, "dead_letter", r.DeadLetter); err != nil {
+				errs = append(errs, err)
+			}
 		}
-		if hasTo && hasGroup {
-			errs = append(errs, fmt.Errorf("routes[%d]: cannot set both 'to' and 'to_group'", i))
+		if r.Reject != nil {
+			if err := checkEgress(i, "reject", r.Reject); err != nil {
+				errs = append(errs, err)
+			}
 		}
-		if hasTo {
-			if _, ok := connectorMap[r.To.Connector]; !ok {
-				errs = append(errs, fmt.Errorf("routes[%d].to.connector %q not found", i, r.To.Connector))
-			} else {
-				if strings.TrimSpace(r.To.Target) == "" {
-					errs = append(errs, fmt.Errorf("routes[%d].to.target is required (egress name)", i))
-				} else {
-					if !egressIdx[egressKey{r.To.Connector, r.To.Target}] {
-						errs = append(errs, fmt.Errorf("routes[%d].to: target %q not found in connector %q", i, r.To.Target, r.To.Connector))
-					}
 
-					if r.To.Connector == r.From.Connector && r.To.Target == r.From.Source {
-						errs = append(errs, fmt.Errorf("routes[%d]: cannot route from and to the same source/target", i))
-					}
+		// to hoặc to_group, hoặc switch
+		if r.Switch != nil {
+			if (r.To != nil && strings.TrimSpace(r.To.Connector) != "") || strings.TrimSpace(r.ToGroup) != "" {
+				errs = append(errs, fmt.Errorf("routes[%d]: cannot set 'switch' together with 'to' or 'to_group'", i))
+			}
+			if len(r.Switch.Cases) == 0 {
+				errs = append(errs, fmt.Errorf("routes[%d].switch.cases is required", i))
+			}
+			for j, c := range r.Switch.Cases {
+				prefix := fmt.Sprintf("routes[%d].switch.cases[%d]", i, j)
+				if strings.TrimSpace(c.When) == "" {
+					errs = append(errs, fmt.Errorf("%s.when is required", prefix))
+				} else if _, err := expr.Compile(c.When); err != nil {
+					errs = append(errs, fmt.Errorf("%s.when: invalid expr: %v", prefix, err))
+				}
+				errs = append(errs, checkDest(prefix, r.From, c.To, c.ToGroup)...)
+			}
+			if d := r.Switch.Default; d != nil {
+				prefix := fmt.Sprintf("routes[%d].switch.default", i)
+				if strings.TrimSpace(d.When) != "" {
+					errs = append(errs, fmt.Errorf("%s: when is not allowed", prefix))
 				}
+				errs = append(errs, checkDest(prefix, r.From, d.To, d.ToGroup)...)
 			}
+		} else {
+			errs = append(errs, checkDest(fmt.Sprintf("routes[%d]", i), r.From, r.To, r.ToGroup)...)
 		}
-		if hasGroup {
-			if !groupSet[r.ToGroup] {
-				errs = append(errs, fmt.Errorf("routes[%d].to_group %q not found", i, r.ToGroup))
+
+		// ordering
+		switch strings.ToLower(r.Ordering) {
+		case "", "none", "strict":
+			if r.PartitionKey != "" {
+				errs = append(errs, fmt.Errorf("routes[%d]: partition_key requires ordering=key", i))
+			}
+		case "key":
+			switch pk := r.PartitionKey; {
+			case pk == "", pk == "route_key", pk == "key":
+			default:
+				if _, _, err := expr.SplitPath(pk); err != nil {
+					errs = append(errs, fmt.Errorf("routes[%d].partition_key: %v", i, err))
+				}
+			}
+		default:
+			errs = append(errs, fmt.Errorf("routes[%d]: ordering must be none|key|strict", i))
+		}
+
+		// dedup
+		if d := r.Dedup; d != nil {
+			switch id := d.IDFrom; {
+			case id == "", id == "idempotencyKey", id == "content_hash":
+			case strings.HasPrefix(id, "header."):
+				if strings.TrimPrefix(id, "header.") == "" {
+					errs = append(errs, fmt.Errorf("routes[%d].dedup.id_from: empty header name", i))
+				}
+			default:
+				if _, _, err := expr.SplitPath(id); err != nil {
+					errs = append(errs, fmt.Errorf("routes[%d].dedup.id_from: %v", i, err))
+				}
+			}
+			if d.TTLms < 0 {
+				errs = append(errs, fmt.Errorf("routes[%d].dedup.ttl_ms must be >= 0", i))
 			}
 		}
 
+		if r.MaxHops < 0 {
+			errs = append(errs, fmt.Errorf("routes[%d].max_hops must be >= 0", i))
+		}
+
 		// mode
 		switch strings.ToLower(r.Mode.Type) {
 		case "persistent":
@@ -268,8 +476,8 @@ func validateRoutesEndpoints(cfg *UserConfig) error {
 				route.Mode.TTLms = 180000 // 3 minutes default
 			}
 
-			if route.Mode.TTLms <= 0 {
-				route.Mode.TTLms = 3 // default 3 attempts
+			if route.Mode.MaxAttempts <= 0 {
+				route.Mode.MaxAttempts = 3 // default 3 attempts
 			}
 		default:
 			errs = append(errs, fmt.Errorf("routes[%d].mode.type must be 'persistent' or 'drop'", i))
@@ -290,6 +498,14 @@ func validateFiltersAndProjections(cfg *UserConfig) error {
 	for _, p := range cfg.Projections {
 		projSet[p.Name] = true
 	}
+	schemaSet := map[string]bool{}
+	for _, sc := range cfg.Schemas {
+		schemaSet[sc.Name] = true
+	}
+	transformSet := map[string]bool{}
+	for _, t := range cfg.Transforms {
+		transformSet[t.Name] = true
+	}
 
 	for i, r := range cfg.Routes {
 		for _, fname := range r.Filters {
@@ -300,11 +516,242 @@ func validateFiltersAndProjections(cfg *UserConfig) error {
 		if r.Projection != "" && !projSet[r.Projection] {
 			errs = append(errs, fmt.Errorf("routes[%d]: projection %q not declared", i, r.Projection))
 		}
+		if r.Schema != "" && !schemaSet[r.Schema] {
+			errs = append(errs, fmt.Errorf("routes[%d]: schema %q not declared", i, r.Schema))
+		}
+		if r.Transform != "" && !transformSet[r.Transform] {
+			errs = append(errs, fmt.Errorf("routes[%d]: transform %q not declared", i, r.Transform))
+		}
+		if r.Reject != nil && r.Schema == "" {
+			errs = append(errs, fmt.Errorf("routes[%d]: reject requires schema", i))
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+func validateFilterRules(cfg *UserConfig) error {
+	var errs []error
+
+	names := map[string]bool{}
+	for i, f := range cfg.Filters {
+		if strings.TrimSpace(f.Name) == "" {
+			errs = append(errs, fmt.Errorf("filters[%d]: name is required", i))
+		} else if names[f.Name] {
+			errs = append(errs, fmt.Errorf("filters[%d]: duplicated filter name %q", i, f.Name))
+		}
+		names[f.Name] = true
+
+		switch strings.ToLower(f.OnMissingField) {
+		case "", "drop", "skip", "false":
+		default:
+			errs = append(errs, fmt.Errorf("filters[%d] %q: on_missing_field must be drop|skip|false", i, f.Name))
+		}
+
+		if _, err := expr.Compile(f.Expr); err != nil {
+			errs = append(errs, fmt.Errorf("filters[%d] %q: invalid expr: %v", i, f.Name, err))
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+func validateProjectionRules(cfg *UserConfig) error {
+	var errs []error
+
+	names := map[string]bool{}
+	for i, p := range cfg.Projections {
+		if strings.TrimSpace(p.Name) == "" {
+			errs = append(errs, fmt.Errorf("projections[%d]: name is required", i))
+		} else if names[p.Name] {
+			errs = append(errs, fmt.Errorf("projections[%d]: duplicated projection name %q", i, p.Name))
+		}
+		names[p.Name] = true
+
+		if len(p.Include) == 0 {
+			errs = append(errs, fmt.Errorf("projections[%d] %q: include must be non-empty", i, p.Name))
+		}
+		for j, inc := range p.Include {
+			if _, _, err := expr.SplitPath(inc); err != nil {
+				errs = append(errs, fmt.Errorf("projections[%d].include[%d]: %v", i, j, err))
+			}
+		}
+
+		switch strings.ToLower(p.OnMissingField) {
+		case "", "drop", "skip", "false":
+		default:
+			errs = append(errs, fmt.Errorf("projections[%d] %q: on_missing_field must be drop|skip|false", i, p.Name))
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+func validateCodecs(cfg *UserConfig) error {
+	var errs []error
+
+	names := map[string]bool{}
+	for i, c := range cfg.Codecs {
+		if strings.TrimSpace(c.Name) == "" {
+			errs = append(errs, fmt.Errorf("codecs[%d]: name is required", i))
+		} else if names[c.Name] {
+			errs = append(errs, fmt.Errorf("codecs[%d]: duplicated codec name %q", i, c.Name))
+		}
+		names[c.Name] = true
+		if strings.TrimSpace(c.Type) == "" {
+			errs = append(errs, fmt.Errorf("codecs[%d] %q: type is required", i, c.Name))
+		}
+		// tham số bắt buộc theo type
+		var required []string
+		switch strings.ToLower(c.Type) {
+		case "protobuf":
+			required = []string{"descriptor_set", "message"}
+		case "avro":
+			required = []string{"schema_file"}
+		}
+		for _, key := range required {
+			if s, _ := c.Params[key].(string); strings.TrimSpace(s) == "" {
+				errs = append(errs, fmt.Errorf("codecs[%d] %q: params.%s is required for type %s", i, c.Name, key, c.Type))
+			}
+		}
+		if strings.EqualFold(c.Type, "schema_registry") {
+			if err := validateSchemaRegistryCodec(i, &c); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+func validateSchemaRules(cfg *UserConfig) error {
+	var errs []error
+
+	names := map[string]bool{}
+	for i, sc := range cfg.Schemas {
+		if strings.TrimSpace(sc.Name) == "" {
+			errs = append(errs, fmt.Errorf("schema[%d]: name is required", i))
+		} else if names[sc.Name] {
+			errs = append(errs, fmt.Errorf("schema[%d]: duplicated schema name %q", i, sc.Name))
+		}
+		names[sc.Name] = true
+
+		switch strings.ToLower(sc.Type) {
+		case "json_schema":
+			if strings.TrimSpace(sc.File) == "" {
+				errs = append(errs, fmt.Errorf("schema[%d] %q: file is required for json_schema", i, sc.Name))
+				continue
+			}
+			if _, err := jsonschema.NewCompiler().Compile(sc.File); err != nil {
+				errs = append(errs, fmt.Errorf("schema[%d] %q: %v", i, sc.Name, err))
+			}
+		case "protobuf":
+			if strings.TrimSpace(sc.DescriptorSet) == "" || strings.TrimSpace(sc.Message) == "" {
+				errs = append(errs, fmt.Errorf("schema[%d] %q: descriptor_set and message are required for protobuf", i, sc.Name))
+			} else if _, err := os.Stat(sc.DescriptorSet); err != nil {
+				errs = append(errs, fmt.Errorf("schema[%d] %q: %v", i, sc.Name, err))
+			}
+			for _, p := range sc.Required {
+				if root, _, err := expr.SplitPath(p); err != nil || root != "payload" {
+					errs = append(errs, fmt.Errorf("schema[%d] %q: required path %q must be payload.*", i, sc.Name, p))
+				}
+			}
+		default:
+			errs = append(errs, fmt.Errorf("schema[%d] %q: type must be json_schema|protobuf", i, sc.Name))
+		}
 	}
 
 	return joinErrors(errs)
 }
 
+func validateTransformRules(cfg *UserConfig) error {
+	var errs []error
+
+	names := map[string]bool{}
+	for i, t := range cfg.Transforms {
+		if strings.TrimSpace(t.Name) == "" {
+			errs = append(errs, fmt.Errorf("transforms[%d]: name is required", i))
+		} else if names[t.Name] {
+			errs = append(errs, fmt.Errorf("transforms[%d]: duplicated transform name %q", i, t.Name))
+		}
+		names[t.Name] = true
+		if len(t.Ops) == 0 {
+			errs = append(errs, fmt.Errorf("transforms[%d] %q: ops is required", i, t.Name))
+		}
+		for j, op := range t.Ops {
+			if err := validateTransformOp(op); err != nil {
+				errs = append(errs, fmt.Errorf("transforms[%d] %q: ops[%d] (%s): %v", i, t.Name, j, op.Op, err))
+			}
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+func validateTransformOp(op TransformOp) error {
+	if _, _, err := expr.SplitPath(op.Field); err != nil {
+		return err
+	}
+	switch strings.ToLower(op.Op) {
+	case "rename", "copy":
+		if strings.TrimSpace(op.To) == "" {
+			return errors.New("to is required")
+		}
+		if _, _, err := expr.SplitPath(op.To); err != nil {
+			return err
+		}
+		if strings.Contains(op.Field, "[]") || strings.Contains(op.To, "[]") {
+			return errors.New("array paths ([]) are not supported")
+		}
+	case "set":
+		if (op.Value == nil) == (op.Expr == "") {
+			return errors.New("exactly one of value or expr is required")
+		}
+		if op.Expr != "" {
+			if _, err := expr.CompileValue(op.Expr); err != nil {
+				return fmt.Errorf("invalid expr: %v", err)
+			}
+		}
+	case "delete", "hash":
+	case "mask":
+		if op.KeepLast < 0 {
+			return errors.New("keep_last must be >= 0")
+		}
+	case "convert":
+		switch strings.ToLower(op.Type) {
+		case "string", "int", "float", "bool":
+		default:
+			return errors.New("type must be string|int|float|bool")
+		}
+	default:
+		return errors.New("op must be rename|copy|set|delete|mask|hash|convert")
+	}
+	return nil
+}
+
+// schema_registry: đúng một trong url/file; format avro|protobuf|json; schema_file cần subject.
+func validateSchemaRegistryCodec(idx int, c *Codec) error {
+	var errs []error
+	str := func(k string) string { s, _ := c.Params[k].(string); return strings.TrimSpace(s) }
+	if (str("url") == "") == (str("file") == "") {
+		errs = append(errs, fmt.Errorf("codecs[%d] %q: exactly one of params.url or params.file is required", idx, c.Name))
+	}
+	switch strings.ToLower(str("format")) {
+	case "", "avro", "protobuf", "json":
+	default:
+		errs = append(errs, fmt.Errorf("codecs[%d] %q: params.format must be avro|protobuf|json", idx, c.Name))
+	}
+	if str("schema_file") != "" {
+		if str("subject") == "" {
+			errs = append(errs, fmt.Errorf("codecs[%d] %q: params.schema_file requires params.subject", idx, c.Name))
+		}
+		if str("format") == "" {
+			errs = append(errs, fmt.Errorf("codecs[%d] %q: params.schema_file requires params.format", idx, c.Name))
+		}
+	}
+	return joinErrors(errs)
+}
+
 // ------- Helpers
 
 func requireStringParam(idx int, c *Connector, key string) error {
@@ -345,6 +792,51 @@ func requireKafkaParams(idx int, c *Connector) error {
 	return nil
 }
 
+// validateNATSAuth: params.auth chỉ được chọn một cách xác thực
+// (username/password | token | nkeySeed/nkeySeedFile | credsFile).
+func validateNATSAuth(idx int, c *Connector) error {
+	raw, ok := c.Params["auth"]
+	if !ok || raw == nil {
+		return nil
+	}
+	auth, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("connectors[%d] %q: params.auth must be a map", idx, c.Name)
+	}
+	str := func(k string) string {
+		s, _ := auth[k].(string)
+		return strings.TrimSpace(s)
+	}
+
+	var errs []error
+	var methods []string
+	if str("username") != "" || str("password") != "" {
+		if str("username") == "" {
+			errs = append(errs, fmt.Errorf("connectors[%d] %q: params.auth.password requires username", idx, c.Name))
+		}
+		methods = append(methods, "username/password")
+	}
+	if str("token") != "" {
+		methods = append(methods, "token")
+	}
+	if str("nkeySeed") != "" || str("nkeySeedFile") != "" {
+		if str("nkeySeed") != "" && str("nkeySeedFile") != "" {
+			errs = append(errs, fmt.Errorf("connectors[%d] %q: params.auth.nkeySeed and nkeySeedFile are mutually exclusive", idx, c.Name))
+		}
+		if seed := str("nkeySeed"); seed != "" && !strings.HasPrefix(seed, "SU") {
+			errs = append(errs, fmt.Errorf("connectors[%d] %q: params.auth.nkeySeed must be a user seed (SU...)", idx, c.Name))
+		}
+		methods = append(methods, "nkey")
+	}
+	if str("credsFile") != "" {
+		methods = append(methods, "credsFile")
+	}
+	if len(methods) > 1 {
+		errs = append(errs, fmt.Errorf("connectors[%d] %q: params.auth has conflicting methods %s (choose one)", idx, c.Name, strings.Join(methods, ", ")))
+	}
+	return joinErrors(errs)
+}
+
 func egName(e *Egress) string {
 	if e.Name != "" {
 		return e.Name
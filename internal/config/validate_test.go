@@ -0,0 +1,27 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTransformOp(t *testing.T) {
+	tests := []struct {
+		op      TransformOp
+		wantErr string
+	}{
+		{TransformOp{Op: "rename", Field: "payload.a", To: "meta.a"}, ""},
+		{TransformOp{Op: "rename", Field: "payload.items[].sku", To: "payload.items[].code"}, "array paths"},
+		{TransformOp{Op: "copy", Field: "payload.sku", To: "payload.items[].sku"}, "array paths"},
+		{TransformOp{Op: "mask", Field: "payload.phones[]", KeepLast: 4}, ""},
+		{TransformOp{Op: "mask", Field: "payload.phone", KeepLast: -1}, "keep_last"},
+		{TransformOp{Op: "set", Field: "payload.a"}, "exactly one of value or expr"},
+		{TransformOp{Op: "convert", Field: "payload.a", Type: "date"}, "type must be"},
+	}
+	for _, tt := range tests {
+		err := validateTransformOp(tt.op)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s %s: err = %v, want %q", tt.op.Op, tt.op.Field, err, tt.wantErr)
+		}
+	}
+}
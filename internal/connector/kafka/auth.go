@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/cuongceg/validate_yaml/internal/connector/tlsutil"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+func buildTLS(t *TLS) (*tls.Config, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return tlsutil.Build(tlsutil.Options{
+		Enabled:            t.Enable,
+		CAFile:             t.CAFile,
+		CertFile:           t.CertFile,
+		KeyFile:            t.KeyFile,
+		InsecureSkipVerify: t.Insecure,
+	})
+}
+
+// buildSASL: PLAIN | SCRAM-SHA-256 | SCRAM-SHA-512
+func buildSASL(s *SASL) (sasl.Mechanism, error) {
+	if s == nil || !s.Enable {
+		return nil, nil
+	}
+	switch strings.ToUpper(s.Mechanism) {
+	case "PLAIN":
+		return plain.Mechanism{Username: s.Username, Password: s.Password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, s.Username, s.Password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, s.Username, s.Password)
+	default:
+		return nil, fmt.Errorf("unsupported sasl mechanism %q", s.Mechanism)
+	}
+}
@@ -5,16 +5,19 @@ import (
 	"time"
 
 	"github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/expr"
 	kafka "github.com/segmentio/kafka-go"
 )
 
 type Connector struct {
-	cfg     Config
-	dialer  *kafka.Dialer
-	readers map[string]*kafka.Reader // map[GroupID]*Reader
-	writers map[string]*kafka.Writer
-	ing     []core.Ingress
-	eg      []core.Egress
+	cfg       Config
+	dialer    *kafka.Dialer
+	transport *kafka.Transport
+	readers   map[string]*kafka.Reader // map[GroupID]*Reader
+	writer    *kafka.Writer            // dùng chung mọi egress, topic đặt theo từng message
+	ing       []core.Ingress
+	eg        []core.Egress
+	health    core.HealthState // kafka-go kết nối lazy: chỉ ghi nhận lỗi fetch/publish gần nhất
 }
 
 func NewKafkaConnector(raw any) (core.Connector, error) {
@@ -23,22 +26,42 @@ func NewKafkaConnector(raw any) (core.Connector, error) {
 		cfg: cfg,
 	}
 	c.readers = make(map[string]*kafka.Reader, len(cfg.Ingresses))
-	c.writers = make(map[string]*kafka.Writer, len(cfg.Egresses))
 	for _, ic := range cfg.Ingresses {
 		c.ing = append(c.ing, &kafkaIngress{cfg: ic, parent: c})
 	}
 	for _, ec := range cfg.Egresses {
-		c.eg = append(c.eg, &kafkaEgress{cfg: ec, parent: c})
+		tpl, err := expr.ParseTemplate(ec.Topic)
+		if err != nil {
+			return nil, fmt.Errorf("kafka egress %q: %w", ec.TargetName, err)
+		}
+		c.eg = append(c.eg, &kafkaEgress{cfg: ec, topic: tpl, parent: c})
 	}
 	return c, nil
 }
 
 func (c *Connector) Open() error {
-	// Dialer dùng chung: TLS/SASL, timeouts
+	tlsCfg, err := buildTLS(c.cfg.TLS)
+	if err != nil {
+		return fmt.Errorf("kafka %q tls: %w", c.cfg.Name, err)
+	}
+	mech, err := buildSASL(c.cfg.SASL)
+	if err != nil {
+		return fmt.Errorf("kafka %q sasl: %w", c.cfg.Name, err)
+	}
+
+	// Dialer (reader) và Transport (writer) dùng chung TLS/SASL, timeouts
 	c.dialer = &kafka.Dialer{
-		Timeout:  10 * time.Second,
-		ClientID: c.cfg.ClientID,
-		//TODO: TLS & SASL config
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		ClientID:      c.cfg.ClientID,
+		TLS:           tlsCfg,
+		SASLMechanism: mech,
+	}
+	c.transport = &kafka.Transport{
+		DialTimeout: 10 * time.Second,
+		ClientID:    c.cfg.ClientID,
+		TLS:         tlsCfg,
+		SASL:        mech,
 	}
 
 	// Tạo Readers theo ingress (Group consumer)
@@ -58,29 +81,34 @@ func (c *Connector) Open() error {
 		c.readers[ic.SourceName] = r
 	}
 
-	for _, ec := range c.cfg.Egresses {
-		if _, ok := c.writers[ec.Topic]; !ok {
-			c.writers[ec.Topic] = &kafka.Writer{
-				Addr:                   kafka.TCP(c.cfg.Brokers...),
-				Topic:                  ec.Topic,
-				Balancer:               &kafka.Hash{}, // hoặc LeastBytes, RoundRobin
-				AllowAutoTopicCreation: false,
-				BatchTimeout:           10 * time.Millisecond,
-				BatchBytes:             128 << 10,
-			}
-		}
+	// Một writer cho mọi topic: Writer.Topic rỗng -> kafka-go dùng Message.Topic,
+	// topic render từ template ({payload.x}) không làm phát sinh writer/goroutine mới
+	c.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(c.cfg.Brokers...),
+		Transport:              c.transport,
+		Balancer:               &kafka.Hash{}, // hoặc LeastBytes, RoundRobin
+		AllowAutoTopicCreation: false,
+		BatchTimeout:           10 * time.Millisecond,
+		BatchBytes:             128 << 10,
 	}
+	c.health.Up()
 	return nil
 }
 
+func (c *Connector) Health() core.Health { return c.health.Health() }
+
 func (c *Connector) Close() error {
 	var err error
 	for _, r := range c.readers {
 		err = r.Close()
 	}
-	for _, w := range c.writers {
-		err = w.Close()
+	if c.writer != nil {
+		err = c.writer.Close()
+	}
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
 	}
+	c.health.Down(nil)
 	fmt.Printf("Kafka %q connector closed\n", c.cfg.Name)
 	return err
 }
@@ -4,28 +4,48 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/expr"
 	kafka "github.com/segmentio/kafka-go"
 )
 
 type kafkaEgress struct {
 	cfg    EgressCfg
+	topic  *expr.Template
 	parent *Connector
 }
 
 func (e *kafkaEgress) TargetName() string { return e.cfg.TargetName }
 
 func (e *kafkaEgress) Publish(ctx context.Context, msg []byte, meta map[string]string) error {
-	w := e.parent.writers[e.cfg.Topic]
+	topic, err := e.topic.Render(meta)
+	if err != nil {
+		return err
+	}
+	w := e.parent.writer
 	if w == nil {
-		return fmt.Errorf("writer not found for topic %s", e.cfg.Topic)
+		return fmt.Errorf("kafka %q not open", e.parent.cfg.Name)
 	}
 
 	key := []byte(meta["key"])
-	return w.WriteMessages(ctx, kafka.Message{
-		Key:   key,
-		Value: msg,
-		// Headers: []kafka.Header{{Key:"...", Value:[]byte("...")}},
+	hdrs := core.Headers(meta)
+	headers := make([]kafka.Header, 0, len(hdrs))
+	for k, v := range hdrs {
+		if k == "key" { // đã là key của message
+			continue
+		}
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	err = w.WriteMessages(ctx, kafka.Message{
+		Topic:   topic,
+		Key:     key,
+		Value:   msg,
+		Headers: headers,
 	})
+	if err != nil {
+		e.parent.health.Fail(fmt.Errorf("write %s: %w", topic, err))
+	}
+	return err
 }
 
 func (e *kafkaEgress) Close() error { return nil }
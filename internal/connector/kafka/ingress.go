@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sync"
+	"io"
+	"strconv"
+	"time"
 
 	"github.com/cuongceg/validate_yaml/internal/core"
 	kafka "github.com/segmentio/kafka-go"
@@ -29,34 +31,90 @@ func (i *kafkaIngress) Start(ctx context.Context, h core.Handler) error {
 	if r == nil {
 		return fmt.Errorf("reader not found for topic %s", i.cfg.Topic)
 	}
-	wg := &sync.WaitGroup{}
-	wg.Add(10)
-	for j := 0; j < 10; j++ {
-		go func() {
-			defer wg.Done()
-			for {
-				m, err := r.FetchMessage(ctx)
-				if err != nil {
-					if errors.Is(err, context.Canceled) {
-						return
-					}
-					// log & tiếp tục
-					continue
-				}
-				meta := map[string]string{}
-				for _, v := range m.Headers {
-					meta[v.Key] = string(v.Value)
+	// Một vòng fetch, chia message cho worker theo partition: message cùng partition
+	// (cùng key) luôn qua handler tuần tự đúng thứ tự offset, partition khác chạy song song.
+	workers := make([]chan kafka.Message, partitionWorkers)
+	for j := range workers {
+		workers[j] = make(chan kafka.Message, 64)
+		go func(q <-chan kafka.Message) {
+			for m := range q {
+				i.handle(ctx, r, m, h)
+			}
+		}(workers[j])
+	}
+	go func() {
+		defer func() {
+			for _, q := range workers {
+				close(q)
+			}
+		}()
+		backoff := time.Duration(0)
+		for {
+			m, err := r.FetchMessage(ctx)
+			if err != nil {
+				// reader đã Close (Connector.Close) -> io.EOF
+				if errors.Is(err, context.Canceled) || errors.Is(err, io.EOF) || ctx.Err() != nil {
+					return
 				}
-				// Handler sẽ CHỈ trả nil sau khi publish RabbitMQ OK (router đảm nhiệm)
-				if err := h(ctx, m.Value, meta); err == nil {
-					_ = r.CommitMessages(ctx, m) // commit offset sau khi downstream OK
-				} else {
-					// tùy chính sách: không commit để retry
+				// lỗi broker: ghi nhận rồi chờ (exponential, có trần) trước khi fetch lại
+				i.parent.health.Fail(fmt.Errorf("fetch %s: %w", i.cfg.Topic, err))
+				backoff = min(max(2*backoff, fetchBackoffMin), fetchBackoffMax)
+				t := time.NewTimer(backoff)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return
+				case <-t.C:
 				}
+				continue
 			}
-		}()
-	}
+			backoff = 0
+			select {
+			case workers[m.Partition%partitionWorkers] <- m:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 	return nil
 }
 
+// partitionWorkers: số message xử lý song song tối đa của một ingress (mỗi worker
+// giữ một nhóm partition).
+const partitionWorkers = 10
+
+// thời gian chờ giữa các lần fetch lỗi liên tiếp
+const (
+	fetchBackoffMin = 100 * time.Millisecond
+	fetchBackoffMax = 10 * time.Second
+)
+
+func (i *kafkaIngress) handle(ctx context.Context, r *kafka.Reader, m kafka.Message, h core.Handler) {
+	meta := map[string]string{}
+	for _, v := range m.Headers {
+		meta[v.Key] = string(v.Value)
+	}
+	if _, ok := meta["key"]; !ok && len(m.Key) > 0 {
+		meta["key"] = string(m.Key)
+	}
+	if _, ok := meta["createdAtMs"]; !ok && !m.Time.IsZero() {
+		meta["createdAtMs"] = strconv.FormatInt(m.Time.UnixMilli(), 10)
+	}
+	// Handler sẽ CHỈ trả nil sau khi publish RabbitMQ OK (router đảm nhiệm)
+	if err := h(ctx, m.Value, meta); core.Acked(err) {
+		_ = r.CommitMessages(ctx, m) // commit offset sau khi downstream OK
+	} else {
+		// tùy chính sách: không commit để retry
+	}
+}
+
 func (i *kafkaIngress) Stop(ctx context.Context) error { return nil }
+
+// Lag: consumer lag (high watermark - offset) mới nhất của reader, -1 nếu chưa có reader.
+func (i *kafkaIngress) Lag() int64 {
+	r := i.parent.readers[i.cfg.SourceName]
+	if r == nil {
+		return -1
+	}
+	return r.Stats().Lag
+}
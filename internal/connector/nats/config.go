@@ -3,6 +3,8 @@ package nats
 import (
 	"crypto/tls"
 	"time"
+
+	"github.com/cuongceg/validate_yaml/internal/connector/tlsutil"
 )
 
 type TLSConfig struct {
@@ -14,12 +16,13 @@ type TLSConfig struct {
 }
 
 type AuthConfig struct {
-	// Choose one of: User/Pass, Token, or NKey (seed/seedFile handled by caller)
+	// Choose one of: User/Pass, Token, NKey (seed or seedFile), or a JWT .creds file
 	Username     string `yaml:"username" json:"username"`
 	Password     string `yaml:"password" json:"password"`
 	Token        string `yaml:"token" json:"token"`
 	NKeySeed     string `yaml:"nkeySeed" json:"nkeySeed"`
 	NKeySeedFile string `yaml:"nkeySeedFile" json:"nkeySeedFile"`
+	CredsFile    string `yaml:"credsFile" json:"credsFile"` // operator/JWT user credentials
 }
 
 type IngressJetStream struct {
@@ -50,7 +53,7 @@ type EgressJetStream struct {
 
 type EgressConfig struct {
 	TargetName string            `yaml:"targetName" json:"targetName"`
-	Subject    string            `yaml:"subject" json:"subject"` // may include ${route_key}
+	Subject    string            `yaml:"subject" json:"subject"` // template: {route_key}, {source_name}, {payload.x}...
 	Headers    map[string]string `yaml:"headers" json:"headers"`
 	JS         EgressJetStream   `yaml:"jetstream" json:"jetstream"`
 }
@@ -60,7 +63,7 @@ type ConnectorConfig struct {
 	Servers       []string      `yaml:"servers" json:"servers"`
 	ClientName    string        `yaml:"clientName" json:"clientName"`
 	ReconnectWait time.Duration `yaml:"reconnectWait" json:"reconnectWait"`
-	MaxReconnects int           `yaml:"maxReconnects" json:"maxReconnects"`
+	MaxReconnects *int          `yaml:"maxReconnects,omitempty" json:"maxReconnects,omitempty"` // không khai báo -> -1 (reconnect mãi); 0 -> không reconnect
 	TLS           TLSConfig     `yaml:"tls" json:"tls"`
 	Auth          AuthConfig    `yaml:"auth" json:"auth"`
 
@@ -68,9 +71,13 @@ type ConnectorConfig struct {
 	Egresses  []EgressConfig  `yaml:"egresses" json:"egresses"`
 }
 
-func (t TLSConfig) ToTLSConfig() *tls.Config {
-	if !t.Enabled {
-		return nil
-	}
-	return &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify} // load CA/cert/key outside
+// ToTLSConfig nạp CA/cert/key; nil nếu TLS không bật.
+func (t TLSConfig) ToTLSConfig() (*tls.Config, error) {
+	return tlsutil.Build(tlsutil.Options{
+		Enabled:            t.Enabled,
+		CAFile:             t.CAFile,
+		CertFile:           t.CertFile,
+		KeyFile:            t.KeyFile,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	})
 }
@@ -2,15 +2,17 @@ package nats
 
 import (
 	"context"
-	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cuongceg/validate_yaml/internal/core"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
 )
 
 type NATSConnector struct {
@@ -21,7 +23,8 @@ type NATSConnector struct {
 	ing []core.Ingress
 	eg  []core.Egress
 
-	mu sync.RWMutex
+	mu     sync.RWMutex
+	health core.HealthState
 }
 
 func NewConnector(cfg ConnectorConfig) *NATSConnector {
@@ -34,28 +37,53 @@ func (c *NATSConnector) Open() error {
 	if len(c.cfg.Servers) == 0 {
 		return errors.New("nats servers not configured")
 	}
+	maxReconnects := -1 // mặc định reconnect mãi; 0 (khai báo rõ) -> không reconnect
+	if c.cfg.MaxReconnects != nil {
+		maxReconnects = *c.cfg.MaxReconnects
+	}
+	reconnectWait := c.cfg.ReconnectWait
+	if reconnectWait <= 0 {
+		reconnectWait = 2 * time.Second
+	}
+	clientName := c.cfg.ClientName
+	if clientName == "" {
+		clientName = c.cfg.Name
+	}
 	opts := []nats.Option{
-		nats.Name(c.cfg.ClientName),
-		nats.MaxReconnects(c.cfg.MaxReconnects),
-		nats.ReconnectWait(c.cfg.ReconnectWait),
-		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) { log.Printf("[NATS] disconnected: %v", err) }),
-		nats.ReconnectHandler(func(nc *nats.Conn) { log.Printf("[NATS] reconnected: %s", nc.ConnectedUrl()) }),
-		nats.ClosedHandler(func(nc *nats.Conn) { log.Printf("[NATS] closed: %v", nc.LastError()) }),
-	}
-	if tlsCfg := c.cfg.TLS.ToTLSConfig(); tlsCfg != nil {
+		nats.Name(clientName),
+		nats.MaxReconnects(maxReconnects),
+		nats.ReconnectWait(reconnectWait),
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			log.Printf("[NATS] disconnected: %v", err)
+			c.health.Down(err)
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Printf("[NATS] reconnected: %s", nc.ConnectedUrl())
+			c.health.Up()
+		}),
+		nats.ClosedHandler(func(nc *nats.Conn) {
+			log.Printf("[NATS] closed: %v", nc.LastError())
+			c.health.Down(nc.LastError())
+		}),
+		nats.ErrorHandler(func(nc *nats.Conn, sub *nats.Subscription, err error) {
+			log.Printf("[NATS] async error: %v", err)
+			c.health.Fail(err)
+		}),
+	}
+	tlsCfg, err := c.cfg.TLS.ToTLSConfig()
+	if err != nil {
+		return fmt.Errorf("nats %q tls: %w", c.cfg.Name, err)
+	}
+	if tlsCfg != nil {
 		opts = append(opts, nats.Secure(tlsCfg))
 	}
 	// auth chain
-	if c.cfg.Auth.Token != "" {
-		opts = append(opts, nats.Token(c.cfg.Auth.Token))
-	} else if c.cfg.Auth.Username != "" || c.cfg.Auth.Password != "" {
-		opts = append(opts, nats.UserInfo(c.cfg.Auth.Username, c.cfg.Auth.Password))
-	} else if c.cfg.Auth.NKeySeed != "" || c.cfg.Auth.NKeySeedFile != "" {
-		kp, err := deriveNKeyPair(c.cfg.Auth)
-		if err != nil {
-			return err
-		}
-		opts = append(opts, nats.Nkey(kp.pub, func(nonce []byte) ([]byte, error) { return ed25519.Sign(kp.priv, nonce), nil }))
+	authOpt, err := authOption(c.cfg.Auth)
+	if err != nil {
+		return err
+	}
+	if authOpt != nil {
+		opts = append(opts, authOpt)
 	}
 
 	url := strings.Join(c.cfg.Servers, ",")
@@ -64,6 +92,7 @@ func (c *NATSConnector) Open() error {
 		return fmt.Errorf("connect nats: %w", err)
 	}
 	c.nc = nc
+	c.health.Up()
 
 	// JetStream if any ingress/egress needs it
 	needJS := false
@@ -96,7 +125,12 @@ func (c *NATSConnector) Open() error {
 	}
 	c.eg = make([]core.Egress, 0, len(c.cfg.Egresses))
 	for _, eg := range c.cfg.Egresses {
-		c.eg = append(c.eg, newEgress(c, eg))
+		out, err := newEgress(c, eg)
+		if err != nil {
+			nc.Close()
+			return err
+		}
+		c.eg = append(c.eg, out)
 	}
 	return nil
 }
@@ -117,18 +151,60 @@ func (c *NATSConnector) Close() error {
 	return nil
 }
 
+func (c *NATSConnector) Health() core.Health { return c.health.Health() }
+
 func (c *NATSConnector) Ingresses() []core.Ingress { return c.ing }
 func (c *NATSConnector) Egresses() []core.Egress   { return c.eg }
 
-type nkeyPair struct {
-	pub  string
-	priv ed25519.PrivateKey
+func authOption(a AuthConfig) (nats.Option, error) {
+	switch {
+	case a.CredsFile != "":
+		if _, err := os.Stat(a.CredsFile); err != nil {
+			return nil, fmt.Errorf("nats creds file: %w", err)
+		}
+		return nats.UserCredentials(a.CredsFile), nil
+	case a.NKeySeed != "" || a.NKeySeedFile != "":
+		kp, err := deriveNKeyPair(a)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := kp.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("nkey public key: %w", err)
+		}
+		return nats.Nkey(pub, kp.Sign), nil
+	case a.Token != "":
+		return nats.Token(a.Token), nil
+	case a.Username != "" || a.Password != "":
+		return nats.UserInfo(a.Username, a.Password), nil
+	}
+	return nil, nil
 }
 
-func deriveNKeyPair(a AuthConfig) (nkeyPair, error) {
-	if a.NKeySeed == "" {
-		return nkeyPair{}, errors.New("nkey seed not provided (seed/seedFile)")
+// deriveNKeyPair parse user seed (SU...) từ nkeySeed hoặc nkeySeedFile.
+// File có thể là seed thuần hoặc dạng "decorated" (có dòng -----BEGIN...).
+func deriveNKeyPair(a AuthConfig) (nkeys.KeyPair, error) {
+	seed := []byte(strings.TrimSpace(a.NKeySeed))
+	if a.NKeySeedFile != "" {
+		b, err := os.ReadFile(a.NKeySeedFile)
+		if err != nil {
+			return nil, fmt.Errorf("read nkey seed file: %w", err)
+		}
+		seed = b
+	}
+	if len(seed) == 0 {
+		return nil, errors.New("nkey seed not provided (seed/seedFile)")
+	}
+	kp, err := nkeys.ParseDecoratedNKey(seed)
+	if err != nil {
+		return nil, fmt.Errorf("parse nkey seed: %w", err)
+	}
+	pub, err := kp.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("parse nkey seed: %w", err)
+	}
+	if !nkeys.IsValidPublicUserKey(pub) {
+		return nil, errors.New("nkey seed is not a user seed (expect SU...)")
 	}
-	// In production, parse seed using nkeys lib. Here we just allocate a key-size buffer.
-	return nkeyPair{pub: "", priv: make(ed25519.PrivateKey, ed25519.PrivateKeySize)}, nil
+	return kp, nil
 }
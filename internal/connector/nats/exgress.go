@@ -2,18 +2,29 @@ package nats
 
 import (
 	"context"
-	"strings"
+	"fmt"
 
+	"github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/expr"
 	"github.com/nats-io/nats.go"
 )
 
 type egress struct {
-	owner *NATSConnector
-	cfg   EgressConfig
+	owner   *NATSConnector
+	cfg     EgressConfig
+	subject *expr.Template
 }
 
-func newEgress(owner *NATSConnector, cfg EgressConfig) *egress {
-	return &egress{owner: owner, cfg: cfg}
+func newEgress(owner *NATSConnector, cfg EgressConfig) (*egress, error) {
+	subj := cfg.Subject
+	if cfg.JS.Enabled && cfg.JS.Subject != "" {
+		subj = cfg.JS.Subject
+	}
+	tpl, err := expr.ParseTemplate(subj)
+	if err != nil {
+		return nil, fmt.Errorf("egress %q: %w", cfg.TargetName, err)
+	}
+	return &egress{owner: owner, cfg: cfg, subject: tpl}, nil
 }
 
 func (e *egress) TargetName() string { return e.cfg.TargetName }
@@ -21,12 +32,9 @@ func (e *egress) TargetName() string { return e.cfg.TargetName }
 func (e *egress) Close() error { return nil }
 
 func (e *egress) Publish(ctx context.Context, msg []byte, meta map[string]string) error {
-	subj := e.cfg.Subject
-	if e.cfg.JS.Enabled && e.cfg.JS.Subject != "" {
-		subj = e.cfg.JS.Subject
-	}
-	if rk := meta["route_key"]; rk != "" {
-		subj = strings.ReplaceAll(subj, "${route_key}", rk)
+	subj, err := e.subject.Render(meta)
+	if err != nil {
+		return err
 	}
 
 	nmsg := &nats.Msg{Subject: subj, Data: msg}
@@ -35,10 +43,8 @@ func (e *egress) Publish(ctx context.Context, msg []byte, meta map[string]string
 		for k, v := range e.cfg.Headers {
 			nmsg.Header.Set(k, v)
 		}
-		for k, v := range meta { // allow meta to add/override selected headers
-			if strings.HasPrefix(k, "hdr:") {
-				nmsg.Header.Set(strings.TrimPrefix(k, "hdr:"), v)
-			}
+		for k, v := range core.Headers(meta) { // meta "hdr:X" ghi đè header cấu hình
+			nmsg.Header.Set(k, v)
 		}
 		if e.cfg.JS.EnableDeDup {
 			if id := meta["idempotencyKey"]; id != "" {
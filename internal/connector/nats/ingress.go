@@ -12,6 +12,9 @@ import (
 	"github.com/tidwall/gjson"
 )
 
+// subject nguồn: egress dùng subject của chính nó
+func init() { core.InternalMeta("subject") }
+
 type ingress struct {
 	owner *NATSConnector
 	cfg   IngressConfig
@@ -119,6 +122,12 @@ func (i *ingress) handle(ctx context.Context, m *nats.Msg, h core.Handler, isJS
 		if id := m.Header.Get(nats.MsgIdHdr); id != "" {
 			meta["idempotencyKey"] = id
 		}
+		// header engine gắn (phát hiện vòng lặp, W3C trace context), luôn giữ lại
+		for _, k := range []string{"msg_id", "hops", "traceparent", "tracestate", "baggage"} {
+			if v := m.Header.Get(k); v != "" {
+				meta[k] = v
+			}
+		}
 	}
 	// KeyFrom
 	if kf := i.cfg.KeyFrom; kf != "" {
@@ -136,7 +145,7 @@ func (i *ingress) handle(ctx context.Context, m *nats.Msg, h core.Handler, isJS
 		}
 	}
 
-	if err := h(ctx, m.Data, meta); err != nil {
+	if err := h(ctx, m.Data, meta); !core.Acked(err) {
 		log.Printf("[NATS] handler error: %v", err)
 		if isJS {
 			_ = m.Nak()
@@ -2,10 +2,9 @@ package rabbitmq
 
 import (
 	"crypto/tls"
-	"crypto/x509"
-	"fmt"
-	"os"
 	"time"
+
+	"github.com/cuongceg/validate_yaml/internal/connector/tlsutil"
 )
 
 type RabbitMQConfig struct {
@@ -49,31 +48,14 @@ type EgressConfig struct {
 }
 
 func buildTLSConfig(opts *TLSOptions) (*tls.Config, error) {
-	if opts == nil || !opts.Enabled {
+	if opts == nil {
 		return nil, nil
 	}
-	cfg := &tls.Config{
+	return tlsutil.Build(tlsutil.Options{
+		Enabled:            opts.Enabled,
+		CAFile:             opts.RootCAPath,
+		CertFile:           opts.ClientCertPath,
+		KeyFile:            opts.ClientKeyPath,
 		InsecureSkipVerify: opts.InsecureSkipVerify,
-		MinVersion:         tls.VersionTLS12,
-	}
-
-	if opts.RootCAPath != "" {
-		caBytes, err := os.ReadFile(opts.RootCAPath)
-		if err != nil {
-			return nil, fmt.Errorf("read root CA: %w", err)
-		}
-		pool := x509.NewCertPool()
-		if ok := pool.AppendCertsFromPEM(caBytes); !ok {
-			return nil, fmt.Errorf("append root CA failed")
-		}
-		cfg.RootCAs = pool
-	}
-	if opts.ClientCertPath != "" && opts.ClientKeyPath != "" {
-		cert, err := tls.LoadX509KeyPair(opts.ClientCertPath, opts.ClientKeyPath)
-		if err != nil {
-			return nil, fmt.Errorf("load client cert/key: %w", err)
-		}
-		cfg.Certificates = []tls.Certificate{cert}
-	}
-	return cfg, nil
+	})
 }
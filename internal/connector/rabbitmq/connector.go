@@ -22,6 +22,7 @@ type Connector struct {
 
 	mu     sync.RWMutex
 	opened bool
+	health core.HealthState
 }
 
 func NewConnector(cfg RabbitMQConfig) *Connector {
@@ -44,7 +45,7 @@ func (c *Connector) Open() error {
 	if c.cfg.TLS != nil && c.cfg.TLS.Enabled {
 		tlsCfg, err := buildTLSConfig(c.cfg.TLS)
 		if err != nil {
-			return err
+			return fmt.Errorf("rabbitmq %q tls: %w", c.cfg.Name, err)
 		}
 		dialCfg.TLSClientConfig = tlsCfg
 	}
@@ -61,6 +62,16 @@ func (c *Connector) Open() error {
 
 	c.conn = conn
 	c.ch = ch
+	c.health.Up()
+	// NotifyClose: broker đóng connection (hoặc mất mạng) -> err != nil; Close() chủ động -> channel đóng
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		if aerr, ok := <-closed; ok && aerr != nil {
+			c.health.Down(aerr)
+			return
+		}
+		c.health.Down(nil)
+	}()
 
 	// Khởi tạo danh sách ingresses / egresses từ config
 	ingresses := make([]core.Ingress, 0, len(c.cfg.Ingresses))
@@ -128,6 +139,8 @@ func (c *Connector) Close() error {
 	return firstErr
 }
 
+func (c *Connector) Health() core.Health { return c.health.Health() }
+
 func (c *Connector) Ingresses() []core.Ingress {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	core "github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/expr"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -22,7 +23,7 @@ type pubLane struct {
 type egress struct {
 	targetName         string
 	exchange           string
-	routingKeyTpl      string
+	routingKeyTpl      *expr.Template
 	persistent         bool
 	defaultContentType string
 	publishTimeout     time.Duration
@@ -38,6 +39,11 @@ func newEgress(conn *amqp.Connection, cfg EgressConfig) (core.Egress, error) {
 		return nil, fmt.Errorf("egress requires targetName")
 	}
 
+	rkTpl, err := expr.ParseTemplate(cfg.RoutingKey)
+	if err != nil {
+		return nil, fmt.Errorf("egress %q: %w", cfg.TargetName, err)
+	}
+
 	poolSize := 16
 
 	lanes := make([]pubLane, 0, poolSize)
@@ -60,7 +66,7 @@ func newEgress(conn *amqp.Connection, cfg EgressConfig) (core.Egress, error) {
 	return &egress{
 		targetName:         cfg.TargetName,
 		exchange:           cfg.Exchange,
-		routingKeyTpl:      cfg.RoutingKey,
+		routingKeyTpl:      rkTpl,
 		persistent:         cfg.Persistent,
 		defaultContentType: cfg.DefaultContentType,
 		publishTimeout:     cfg.PublishTimeout,
@@ -89,15 +95,23 @@ func (e *egress) Publish(ctx context.Context, msg []byte, meta map[string]string
 	}
 
 	hdrs := amqp.Table{}
-	for k, v := range meta {
+	for k, v := range core.Headers(meta) {
 		hdrs[k] = v
 	}
 
-	// routing key (tạm: dùng template string sẵn)
-	rk := e.routingKeyTpl
+	rk, err := e.routingKeyTpl.Render(meta)
+	if err != nil {
+		return err
+	}
+
+	// content-type theo codec của route; không có thì dùng mặc định của egress
+	contentType := meta["content-type"]
+	if contentType == "" {
+		contentType = e.defaultContentType
+	}
 
 	pub := amqp.Publishing{
-		ContentType:  "application/x-protobuf",
+		ContentType:  contentType,
 		Body:         msg,
 		Headers:      hdrs,
 		DeliveryMode: amqp.Transient,
@@ -10,6 +10,9 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// thuộc tính delivery, không phải header của message
+func init() { core.InternalMeta("exchange", "routing-key", "delivery-tag") }
+
 type ingress struct {
 	sourceName string
 	queue      string
@@ -98,13 +101,13 @@ func (i *ingress) Start(ctx context.Context, h core.Handler) error {
 				err := h(ctx, d.Body, meta)
 				if i.autoAck {
 					// autoAck -> broker đã ack ngay khi gửi, không cần xử lý.
-					if err != nil {
+					if !core.Acked(err) {
 						// Có thể log lỗi để quan sát
 						log.Printf("[rabbitmq ingress %s] handler error (autoAck): %v", i.sourceName, err)
 					}
 					continue
 				}
-				if err != nil {
+				if !core.Acked(err) {
 					_ = d.Nack(false /*multiple*/, i.requeueOnError)
 				} else {
 					_ = d.Ack(false /*multiple*/)
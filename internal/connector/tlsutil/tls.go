@@ -0,0 +1,52 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// Options dùng chung cho các connector (kafka, nats, rabbitmq).
+type Options struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// Build nạp CA/cert/key từ file; trả nil nếu TLS không bật.
+// Lỗi đọc file được trả về ngay để connector báo lỗi lúc Open thay vì lúc handshake.
+func Build(opts Options) (*tls.Config, error) {
+	if !opts.Enabled {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if opts.CAFile != "" {
+		caBytes, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read root CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM(caBytes); !ok {
+			return nil, fmt.Errorf("append root CA failed: no PEM certificate in %s", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, fmt.Errorf("client cert and key must be provided together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert/key: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -1,9 +1,22 @@
 package core
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
+// ErrFiltered: handler đã xử lý xong nhưng message bị filter/projection loại.
+// Ingress phải coi như thành công (ack/commit) chứ không retry.
+var ErrFiltered = errors.New("message filtered")
+
+// Handler trả nil hoặc ErrFiltered (có thể wrap) -> ack; lỗi khác -> không ack/nack.
 type Handler func(ctx context.Context, msg []byte, meta map[string]string) error
 
+// Acked cho biết kết quả của Handler có được ack/commit hay không.
+func Acked(err error) bool {
+	return err == nil || errors.Is(err, ErrFiltered)
+}
+
 type Connector interface {
 	Name() string
 	Open() error
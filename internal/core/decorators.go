@@ -5,14 +5,87 @@ import (
 	"time"
 )
 
-type EgressWithLog struct{ Next Egress }
+// Observer nhận sự kiện từ các decorator quanh Ingress/Egress (metrics, log, ...).
+// connector là tên connector, name là source_name (ingress) hoặc target (egress).
+type Observer interface {
+	Received(connector, source string)
+	Handled(connector, source string, d time.Duration, err error)
+	Published(connector, target string, d time.Duration, err error)
+}
+
+// LagReporter: ingress có consumer lag (vd Kafka) cài thêm interface này.
+type LagReporter interface {
+	Lag() int64
+}
+
+// IngressWithObserver báo mỗi message nhận được và kết quả handler (ack/filtered/lỗi).
+type IngressWithObserver struct {
+	Next      Ingress
+	Connector string
+	Obs       Observer
+}
+
+func (d IngressWithObserver) SourceName() string             { return d.Next.SourceName() }
+func (d IngressWithObserver) Stop(ctx context.Context) error { return d.Next.Stop(ctx) }
+func (d IngressWithObserver) Start(ctx context.Context, h Handler) error {
+	src := d.Next.SourceName()
+	return d.Next.Start(ctx, func(ctx context.Context, msg []byte, meta map[string]string) error {
+		d.Obs.Received(d.Connector, src)
+		t0 := time.Now()
+		err := h(ctx, msg, meta)
+		d.Obs.Handled(d.Connector, src, time.Since(t0), err)
+		return err
+	})
+}
+
+// Lag chuyển tiếp tới ingress gốc; -1 nếu ingress không báo lag.
+func (d IngressWithObserver) Lag() int64 {
+	if lr, ok := d.Next.(LagReporter); ok {
+		return lr.Lag()
+	}
+	return -1
+}
+
+// EgressWithLog đo thời gian publish và báo cho Observer.
+type EgressWithLog struct {
+	Next      Egress
+	Connector string
+	Obs       Observer
+}
 
 func (d EgressWithLog) TargetName() string { return d.Next.TargetName() }
 func (d EgressWithLog) Close() error       { return d.Next.Close() }
 func (d EgressWithLog) Publish(ctx context.Context, msg []byte, meta map[string]string) error {
 	t0 := time.Now()
 	err := d.Next.Publish(ctx, msg, meta)
-	// TODO: log/metric/tracing…
-	_ = t0
+	if d.Obs != nil {
+		d.Obs.Published(d.Connector, d.Next.TargetName(), time.Since(t0), err)
+	}
 	return err
 }
+
+// observedConnector bọc mọi ingress/egress của connector bằng decorator ở trên.
+type observedConnector struct {
+	Connector
+	ing []Ingress
+	eg  []Egress
+}
+
+func (c *observedConnector) Ingresses() []Ingress { return c.ing }
+func (c *observedConnector) Egresses() []Egress   { return c.eg }
+func (c *observedConnector) Unwrap() Connector    { return c.Connector }
+
+// WithObserver trả connector có ingress/egress đã được bọc; obs nil -> giữ nguyên.
+func WithObserver(c Connector, obs Observer) Connector {
+	if obs == nil {
+		return c
+	}
+	oc := &observedConnector{Connector: c}
+	for _, in := range c.Ingresses() {
+		oc.ing = append(oc.ing, IngressWithObserver{Next: in, Connector: c.Name(), Obs: obs})
+	}
+	for _, eg := range c.Egresses() {
+		oc.eg = append(oc.eg, EgressWithLog{Next: eg, Connector: c.Name(), Obs: obs})
+	}
+	return oc
+}
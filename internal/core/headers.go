@@ -0,0 +1,65 @@
+package core
+
+import (
+	"strings"
+	"sync"
+)
+
+// HeaderPrefix: meta "hdr:X" luôn được gửi thành header X (ghi đè header cùng tên).
+const HeaderPrefix = "hdr:"
+
+// Egress gửi mọi meta thành header (giữ nguyên header gốc của nguồn cho consumer phía
+// sau), trừ key nội bộ do connector/router đăng ký qua InternalMeta/InternalMetaPrefix:
+// route, size, source_*, delivery-tag, subject, giá trị {payload.*} cho template...
+var (
+	internalMu       sync.RWMutex
+	internalKeys     = map[string]bool{}
+	internalPrefixes []string
+)
+
+// InternalMeta đánh dấu meta key chỉ dùng nội bộ, không gửi thành header;
+// gọi trong init() của package đặt key đó.
+func InternalMeta(keys ...string) {
+	internalMu.Lock()
+	defer internalMu.Unlock()
+	for _, k := range keys {
+		internalKeys[k] = true
+	}
+}
+
+// InternalMetaPrefix như InternalMeta nhưng theo tiền tố (vd "payload.").
+func InternalMetaPrefix(prefixes ...string) {
+	internalMu.Lock()
+	defer internalMu.Unlock()
+	internalPrefixes = append(internalPrefixes, prefixes...)
+}
+
+// Headers lọc meta thành header gửi ra broker.
+func Headers(meta map[string]string) map[string]string {
+	internalMu.RLock()
+	defer internalMu.RUnlock()
+	out := make(map[string]string, len(meta))
+	for k, v := range meta {
+		if !strings.HasPrefix(k, HeaderPrefix) && !internalLocked(k) {
+			out[k] = v
+		}
+	}
+	for k, v := range meta {
+		if name, ok := strings.CutPrefix(k, HeaderPrefix); ok && name != "" {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+func internalLocked(k string) bool {
+	if internalKeys[k] {
+		return true
+	}
+	for _, p := range internalPrefixes {
+		if strings.HasPrefix(k, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestHeaders(t *testing.T) {
+	InternalMeta("route", "size")
+	InternalMetaPrefix("payload.")
+
+	got := Headers(map[string]string{
+		"x-tenant":         "acme", // header gốc của nguồn: giữ nguyên
+		"traceparent":      "00-abc",
+		"route":            "r1",
+		"size":             "12",
+		"payload.order.id": "42",
+		"hdr:route":        "public-route", // hdr: luôn được gửi, kể cả tên trùng key nội bộ
+		"hdr:":             "ignored",
+	})
+	want := map[string]string{"x-tenant": "acme", "traceparent": "00-abc", "route": "public-route"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Headers = %v, want %v", got, want)
+	}
+}
+
+// đăng ký song song với egress đang publish: không race (go test -race).
+func TestHeadersConcurrentRegistration(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); InternalMeta("k") }()
+		go func() { defer wg.Done(); Headers(map[string]string{"k": "v", "a": "b"}) }()
+	}
+	wg.Wait()
+	if _, ok := Headers(map[string]string{"k": "v"})["k"]; ok {
+		t.Fatal("internal key k sent as header")
+	}
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Health: trạng thái kết nối của connector tới broker (admin API / readiness).
+type Health struct {
+	Connected   bool      `json:"connected"`
+	Since       time.Time `json:"since"` // lần đổi trạng thái gần nhất
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+}
+
+// HealthReporter: connector báo được trạng thái kết nối cài thêm interface này.
+type HealthReporter interface {
+	Health() Health
+}
+
+// HealthState giữ Health, an toàn khi gọi từ callback của client (disconnect, NotifyClose...).
+type HealthState struct {
+	mu sync.Mutex
+	h  Health
+}
+
+// Up: đã kết nối (hoặc reconnect thành công).
+func (s *HealthState) Up() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.h.Connected || s.h.Since.IsZero() {
+		s.h.Connected, s.h.Since = true, time.Now()
+	}
+}
+
+// Down: mất kết nối; err có thể nil (đóng chủ động).
+func (s *HealthState) Down(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.h.Connected || s.h.Since.IsZero() {
+		s.h.Connected, s.h.Since = false, time.Now()
+	}
+	if err != nil {
+		s.h.LastError, s.h.LastErrorAt = err.Error(), time.Now()
+	}
+}
+
+// Fail ghi lỗi gần nhất mà không đổi trạng thái kết nối (vd lỗi fetch tạm thời).
+func (s *HealthState) Fail(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.h.LastError, s.h.LastErrorAt = err.Error(), time.Now()
+}
+
+func (s *HealthState) Health() Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.h
+}
+
+// HealthOf đọc Health của connector, bóc qua các decorator (Unwrap).
+func HealthOf(c Connector) (Health, bool) {
+	for c != nil {
+		if hr, ok := c.(HealthReporter); ok {
+			return hr.Health(), true
+		}
+		u, ok := c.(interface{ Unwrap() Connector })
+		if !ok {
+			break
+		}
+		c = u.Unwrap()
+	}
+	return Health{}, false
+}
@@ -0,0 +1,34 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore dùng chung giữa nhiều instance bridge.
+type RedisStore struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStore(rdb redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "bridge:dedup:"
+	}
+	return &RedisStore{rdb: rdb, prefix: prefix}
+}
+
+// Claim: SET NX PX, atomic giữa các instance.
+func (s *RedisStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, s.prefix+key, time.Now().UnixMilli(), ttl).Result()
+}
+
+func (s *RedisStore) Mark(ctx context.Context, key string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, s.prefix+key, time.Now().UnixMilli(), ttl).Err()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, s.prefix+key).Err()
+}
@@ -0,0 +1,78 @@
+// Package dedup ghi nhận message đã giao (theo route/target/message ID) để bỏ qua bản lặp.
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store lưu ID đã giao với TTL. Claim giữ key trước khi publish (set-if-absent, nên
+// hai bản lặp chạy đồng thời chỉ một bản qua); publish thành công thì Mark gia hạn
+// key theo TTL dedup, thất bại thì Release để message còn được giao lại.
+type Store interface {
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Mark(ctx context.Context, key string, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore lưu trong process (test, hoặc chạy một instance không có Redis).
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time // key -> hết hạn
+	now     func() time.Time
+	lastGC  time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]time.Time{}, now: time.Now}
+}
+
+func (s *MemoryStore) Claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if exp, ok := s.entries[key]; ok && (exp.IsZero() || now.Before(exp)) {
+		return false, nil
+	}
+	s.setLocked(now, key, ttl)
+	return true, nil
+}
+
+func (s *MemoryStore) Mark(_ context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(s.now(), key, ttl)
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) setLocked(now time.Time, key string, ttl time.Duration) {
+	var exp time.Time
+	if ttl > 0 {
+		exp = now.Add(ttl)
+	}
+	s.entries[key] = exp
+	// dọn key hết hạn định kỳ
+	if now.Sub(s.lastGC) > time.Minute {
+		for k, e := range s.entries {
+			if !e.IsZero() && !now.Before(e) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastGC = now
+	}
+}
+
+// Len: số key đang giữ (kể cả chưa dọn).
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
@@ -0,0 +1,96 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock: MemoryStore.now cho test TTL không cần sleep.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time      { return c.t }
+func (c *fakeClock) add(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clk := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	s := NewMemoryStore()
+	s.now = clk.now
+	return s, clk
+}
+
+func TestMemoryStoreClaim(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStore()
+
+	if ok, _ := s.Claim(ctx, "k", time.Minute); !ok {
+		t.Fatal("first claim should succeed")
+	}
+	if ok, _ := s.Claim(ctx, "k", time.Minute); ok {
+		t.Fatal("second claim should see the key")
+	}
+	if err := s.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Claim(ctx, "k", time.Minute); !ok {
+		t.Fatal("claim after release should succeed")
+	}
+}
+
+func TestMemoryStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	s, clk := newTestStore()
+
+	s.Claim(ctx, "k", time.Second)
+	if err := s.Mark(ctx, "k", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	clk.add(30 * time.Minute)
+	if ok, _ := s.Claim(ctx, "k", time.Second); ok {
+		t.Fatal("mark should extend the key to its TTL")
+	}
+	clk.add(31 * time.Minute)
+	if ok, _ := s.Claim(ctx, "k", time.Second); !ok {
+		t.Fatal("key should expire after its TTL")
+	}
+	// claim chưa Mark tự hết hạn (process dừng giữa chừng)
+	clk.add(2 * time.Second)
+	if ok, _ := s.Claim(ctx, "k", time.Second); !ok {
+		t.Fatal("unmarked claim should expire")
+	}
+}
+
+func TestMemoryStoreGC(t *testing.T) {
+	ctx := context.Background()
+	s, clk := newTestStore()
+	for _, k := range []string{"a", "b", "c"} {
+		s.Mark(ctx, k, time.Second)
+	}
+	clk.add(2 * time.Minute)
+	s.Mark(ctx, "d", time.Second)
+	if n := s.Len(); n != 1 {
+		t.Fatalf("Len = %d after GC, want 1", n)
+	}
+}
+
+func TestMemoryStoreConcurrentClaim(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	var won atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := s.Claim(ctx, "k", time.Minute); ok {
+				won.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := won.Load(); n != 1 {
+		t.Fatalf("%d claims won, want 1", n)
+	}
+}
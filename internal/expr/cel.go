@@ -0,0 +1,242 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// Program là một biểu thức CEL đã compile, trả về bool.
+// Biến có sẵn: payload (map), meta (map); hàm: nowMs().
+type Program struct {
+	src     string
+	prg     cel.Program
+	payload bool       // biểu thức có đọc biến payload
+	paths   [][]string // đường dẫn payload.*/meta.* được đọc (trừ trường cuối trong has())
+}
+
+var (
+	envOnce sync.Once
+	env     *cel.Env
+	envErr  error
+)
+
+func sharedEnv() (*cel.Env, error) {
+	envOnce.Do(func() {
+		env, envErr = cel.NewEnv(
+			cel.Variable("payload", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("meta", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Function("nowMs",
+				cel.Overload("nowMs_int", []*cel.Type{}, cel.IntType,
+					cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+						return types.Int(time.Now().UnixMilli())
+					}),
+				),
+			),
+			cel.CrossTypeNumericComparisons(true),
+		)
+	})
+	return env, envErr
+}
+
+// Compile parse + type-check biểu thức; lỗi cú pháp/kiểu được trả về ngay.
+func Compile(src string) (*Program, error) {
+	return compile(src, true)
+}
+
+// CompileValue như Compile nhưng biểu thức trả giá trị bất kỳ (dùng với Value).
+func CompileValue(src string) (*Program, error) {
+	return compile(src, false)
+}
+
+func compile(src string, wantBool bool) (*Program, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errors.New("empty expression")
+	}
+	e, err := sharedEnv()
+	if err != nil {
+		return nil, fmt.Errorf("cel env: %w", err)
+	}
+	ast, iss := e.Compile(src)
+	if iss != nil && iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if t := ast.OutputType(); wantBool && !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression must return bool, got %s", t)
+	}
+	prg, err := e.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	payload := false
+	for _, r := range ast.NativeRep().ReferenceMap() {
+		if r.Name == "payload" {
+			payload = true
+			break
+		}
+	}
+	return &Program{src: src, prg: prg, payload: payload, paths: fieldPaths(ast.NativeRep().Expr())}, nil
+}
+
+// fieldPaths gom các chuỗi select/index hằng bắt đầu từ payload hoặc meta,
+// vd payload.a.b, meta["k"]; has(payload.a.b) chỉ đòi payload.a tồn tại.
+func fieldPaths(root ast.Expr) [][]string {
+	var out [][]string
+	ast.PostOrderVisit(root, ast.NewExprVisitor(func(e ast.Expr) {
+		if e.Kind() == ast.SelectKind && e.AsSelect().IsTestOnly() {
+			return
+		}
+		if segs := selectPath(e); len(segs) > 1 {
+			out = append(out, segs)
+		}
+	}))
+	return out
+}
+
+func selectPath(e ast.Expr) []string {
+	switch e.Kind() {
+	case ast.IdentKind:
+		if n := e.AsIdent(); n == "payload" || n == "meta" {
+			return []string{n}
+		}
+	case ast.SelectKind:
+		sel := e.AsSelect()
+		if base := selectPath(sel.Operand()); base != nil {
+			return append(base, sel.FieldName())
+		}
+	case ast.CallKind:
+		call := e.AsCall()
+		if call.FunctionName() != operators.Index || len(call.Args()) != 2 {
+			return nil
+		}
+		if call.Args()[1].Kind() != ast.LiteralKind {
+			return nil
+		}
+		key, ok := call.Args()[1].AsLiteral().Value().(string)
+		if !ok {
+			return nil
+		}
+		if base := selectPath(call.Args()[0]); base != nil {
+			return append(base, key)
+		}
+	}
+	return nil
+}
+
+func (p *Program) String() string { return p.src }
+
+// UsesPayload: biểu thức tham chiếu payload (cần codec decode được).
+func (p *Program) UsesPayload() bool { return p.payload }
+
+// Eval chạy biểu thức với payload/meta. nil được thay bằng map rỗng để has() hoạt động.
+func (p *Program) Eval(payload, meta map[string]any) (bool, error) {
+	out, err := p.eval(payload, meta)
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q returned %T, expected bool", p.src, out.Value())
+	}
+	return b, nil
+}
+
+// Value chạy biểu thức và trả giá trị Go (int64, float64, string, bool, []any, map[string]any...).
+func (p *Program) Value(payload, meta map[string]any) (any, error) {
+	out, err := p.eval(payload, meta)
+	if err != nil {
+		return nil, err
+	}
+	return nativeValue(out)
+}
+
+func (p *Program) eval(payload, meta map[string]any) (ref.Val, error) {
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	vars := map[string]any{
+		"payload": payload,
+		"meta":    meta,
+	}
+	out, _, err := p.prg.Eval(vars)
+	if err != nil {
+		if path, ok := p.missing(vars); ok {
+			return nil, &MissingFieldError{Path: path, Err: err}
+		}
+	}
+	return out, err
+}
+
+// missing trả đường dẫn đầu tiên mà biểu thức đọc nhưng không có trong dữ liệu.
+func (p *Program) missing(vars map[string]any) (string, bool) {
+	for _, segs := range p.paths {
+		if _, ok := Lookup(vars, segs); !ok {
+			return strings.Join(segs, "."), true
+		}
+	}
+	return "", false
+}
+
+func nativeValue(v ref.Val) (any, error) {
+	switch vv := v.(type) {
+	case traits.Lister:
+		n, _ := vv.Size().Value().(int64)
+		out := make([]any, 0, n)
+		for it := vv.Iterator(); it.HasNext() == types.True; {
+			e, err := nativeValue(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, e)
+		}
+		return out, nil
+	case traits.Mapper:
+		out := map[string]any{}
+		for it := vv.Iterator(); it.HasNext() == types.True; {
+			k := it.Next()
+			ks, ok := k.Value().(string)
+			if !ok {
+				return nil, fmt.Errorf("map key must be string, got %s", k.Type())
+			}
+			e, err := nativeValue(vv.Get(k))
+			if err != nil {
+				return nil, err
+			}
+			out[ks] = e
+		}
+		return out, nil
+	case types.Null:
+		return nil, nil
+	}
+	return v.Value(), nil
+}
+
+// MissingFieldError: Eval lỗi và biểu thức có đọc một trường không tồn tại.
+type MissingFieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *MissingFieldError) Error() string {
+	return fmt.Sprintf("missing field %s: %v", e.Path, e.Err)
+}
+
+func (e *MissingFieldError) Unwrap() error { return e.Err }
+
+// IsMissingField cho biết lỗi Eval có phải do truy cập trường không tồn tại.
+func IsMissingField(err error) bool {
+	var mf *MissingFieldError
+	return errors.As(err, &mf)
+}
@@ -0,0 +1,71 @@
+package expr
+
+import (
+	"testing"
+)
+
+func TestMissingField(t *testing.T) {
+	payload := map[string]any{"order": map[string]any{"total": int64(5)}, "tags": []any{"a"}}
+	meta := map[string]any{"tenant": "acme"}
+
+	tests := []struct {
+		src         string
+		want        bool
+		wantMissing bool
+	}{
+		{`payload.order.total > 1`, true, false},
+		{`payload.order.missing > 1`, false, true},
+		{`payload.customer.id == "x"`, false, true},
+		{`payload["order"]["missing"] == 1`, false, true},
+		{`meta.tenant == "acme"`, true, false},
+		{`meta.region == "eu"`, false, true},
+		{`meta["region"] == "eu"`, false, true},
+		// has() kiểm tra trường cuối, không lỗi
+		{`has(payload.order.missing)`, false, false},
+		{`has(payload.customer) && payload.customer.id == "x"`, false, false},
+		// lỗi không do thiếu trường: trả lỗi thường
+		{`payload.order.total / 0 == 1`, false, false},
+	}
+	for _, tt := range tests {
+		prg, err := Compile(tt.src)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.src, err)
+		}
+		got, err := prg.Eval(payload, meta)
+		if IsMissingField(err) != tt.wantMissing {
+			t.Errorf("%s: IsMissingField(%v) = %v, want %v", tt.src, err, !tt.wantMissing, tt.wantMissing)
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestMissingFieldPath(t *testing.T) {
+	prg, err := CompileValue(`payload.a.b + meta.n`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = prg.Value(map[string]any{"a": map[string]any{"b": int64(1)}}, nil)
+	mf, ok := err.(*MissingFieldError)
+	if !ok || mf.Path != "meta.n" {
+		t.Fatalf("err = %v, want missing meta.n", err)
+	}
+}
+
+func TestUsesPayload(t *testing.T) {
+	for src, want := range map[string]bool{
+		`payload.a == 1`:      true,
+		`has(payload.a)`:      true,
+		`meta.k == "v"`:       false,
+		`nowMs() > 0 && true`: false,
+	} {
+		prg, err := Compile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prg.UsesPayload() != want {
+			t.Errorf("%s: UsesPayload = %v, want %v", src, !want, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitPath tách "payload.a.b" -> ("payload", [a b]).
+func SplitPath(p string) (root string, segs []string, err error) {
+	parts := strings.Split(strings.TrimSpace(p), ".")
+	root = parts[0]
+	if root != "payload" && root != "meta" {
+		return "", nil, fmt.Errorf("path %q must start with payload or meta", p)
+	}
+	for _, s := range parts[1:] {
+		if strings.TrimSuffix(s, "[]") == "" {
+			return "", nil, fmt.Errorf("path %q has an empty segment", p)
+		}
+	}
+	if root == "meta" && len(parts) != 2 {
+		return "", nil, fmt.Errorf("path %q: meta is flat, expect meta.<key>", p)
+	}
+	return root, parts[1:], nil
+}
+
+// Lookup lấy giá trị theo segments trong map lồng nhau (không duyệt mảng).
+func Lookup(obj map[string]any, segs []string) (any, bool) {
+	var cur any = obj
+	for _, s := range segs {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[strings.TrimSuffix(s, "[]")]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Set gán giá trị tại segments, tạo map trung gian nếu thiếu; segment "x[]"
+// áp dụng cho từng phần tử (map) của mảng x đang có.
+func Set(obj map[string]any, segs []string, v any) {
+	walk(obj, segs, true, func(m map[string]any, key string) {
+		m[key] = v
+	})
+}
+
+// Delete xóa trường tại segments (bỏ qua nếu không có).
+func Delete(obj map[string]any, segs []string) {
+	walk(obj, segs, false, func(m map[string]any, key string) {
+		delete(m, key)
+	})
+}
+
+// Update thay giá trị đang có tại segments bằng fn(v); trường không có thì bỏ qua.
+func Update(obj map[string]any, segs []string, fn func(v any) (any, error)) error {
+	var rerr error
+	walk(obj, segs, false, func(m map[string]any, key string) {
+		old, ok := m[key]
+		if !ok || rerr != nil {
+			return
+		}
+		nv, err := fn(old)
+		if err != nil {
+			rerr = err
+			return
+		}
+		m[key] = nv
+	})
+	return rerr
+}
+
+func walk(m map[string]any, segs []string, create bool, leaf func(m map[string]any, key string)) {
+	if len(segs) == 0 || m == nil {
+		return
+	}
+	key := strings.TrimSuffix(segs[0], "[]")
+	isArr := key != segs[0]
+	if len(segs) == 1 && !isArr {
+		leaf(m, key)
+		return
+	}
+	if isArr {
+		items, _ := m[key].([]any)
+		for _, it := range items {
+			if im, ok := it.(map[string]any); ok {
+				walk(im, segs[1:], create, leaf)
+			}
+		}
+		return
+	}
+	next, ok := m[key].(map[string]any)
+	if !ok {
+		if !create {
+			return
+		}
+		next = map[string]any{}
+		m[key] = next
+	}
+	walk(next, segs[1:], create, leaf)
+}
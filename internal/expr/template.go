@@ -0,0 +1,152 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholder dạng {name} (hoặc ${name}) dùng trong topic_template, subject_template,
+// routing_key_template:
+//   - {source_name}, {source_connector}, {route}, {route_key}, {key}, {subject}: meta chuẩn
+//   - {meta.X} / {header.X}: meta/header bất kỳ
+//   - {payload.a.b}: trường trong payload (router đưa vào meta với key "payload.a.b")
+var knownPlaceholders = map[string]bool{
+	"source_name":      true,
+	"source_connector": true,
+	"route":            true,
+	"route_key":        true,
+	"key":              true,
+	"subject":          true,
+}
+
+type tplPart struct {
+	lit string
+	ref string // rỗng nếu là literal
+}
+
+// Template là template đã parse; Render chỉ thay thế, không parse lại.
+type Template struct {
+	src   string
+	parts []tplPart
+}
+
+func ParseTemplate(src string) (*Template, error) {
+	t := &Template{src: src}
+	rest := src
+	for len(rest) > 0 {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			if strings.IndexByte(rest, '}') >= 0 {
+				return nil, fmt.Errorf("template %q: unexpected '}'", src)
+			}
+			t.parts = append(t.parts, tplPart{lit: rest})
+			break
+		}
+		lit := rest[:open]
+		if strings.HasSuffix(lit, "$") {
+			lit = lit[:len(lit)-1]
+		}
+		if strings.IndexByte(lit, '}') >= 0 {
+			return nil, fmt.Errorf("template %q: unexpected '}'", src)
+		}
+		if lit != "" {
+			t.parts = append(t.parts, tplPart{lit: lit})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("template %q: unclosed '{'", src)
+		}
+		ref := strings.TrimSpace(rest[open+1 : open+end])
+		if err := checkPlaceholder(ref); err != nil {
+			return nil, fmt.Errorf("template %q: %w", src, err)
+		}
+		t.parts = append(t.parts, tplPart{ref: ref})
+		rest = rest[open+end+1:]
+	}
+	return t, nil
+}
+
+func checkPlaceholder(ref string) error {
+	if ref == "" {
+		return fmt.Errorf("empty placeholder {}")
+	}
+	if knownPlaceholders[ref] {
+		return nil
+	}
+	if root, key, ok := strings.Cut(ref, "."); ok {
+		switch root {
+		case "meta", "header":
+			if key != "" {
+				return nil
+			}
+		case "payload":
+			_, _, err := SplitPath(ref)
+			return err
+		}
+	}
+	return fmt.Errorf("unknown placeholder {%s}", ref)
+}
+
+func (t *Template) String() string { return t.src }
+
+// Static: template không có placeholder.
+func (t *Template) Static() bool {
+	for _, p := range t.parts {
+		if p.ref != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches: s có thể là kết quả Render của template (placeholder = chuỗi bất kỳ khác rỗng).
+func (t *Template) Matches(s string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, p := range t.parts {
+		if p.ref == "" {
+			b.WriteString(regexp.QuoteMeta(p.lit))
+		} else {
+			b.WriteString(".+")
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()).MatchString(s)
+}
+
+// PayloadPaths trả các placeholder {payload.*} (giữ nguyên tiền tố payload.).
+func (t *Template) PayloadPaths() []string {
+	var out []string
+	for _, p := range t.parts {
+		if strings.HasPrefix(p.ref, "payload.") {
+			out = append(out, p.ref)
+		}
+	}
+	return out
+}
+
+// Render thay placeholder bằng giá trị trong meta; thiếu giá trị là lỗi
+// để không publish nhầm sang topic/subject rỗng.
+func (t *Template) Render(meta map[string]string) (string, error) {
+	if len(t.parts) == 1 && t.parts[0].ref == "" {
+		return t.parts[0].lit, nil
+	}
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.ref == "" {
+			b.WriteString(p.lit)
+			continue
+		}
+		key := p.ref
+		if root, k, ok := strings.Cut(p.ref, "."); ok && (root == "meta" || root == "header") {
+			key = k
+		}
+		v, ok := meta[key]
+		if !ok || v == "" {
+			return "", fmt.Errorf("template %q: no value for {%s}", t.src, p.ref)
+		}
+		b.WriteString(v)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,88 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		src, wantErr string
+	}{
+		{"orders.{unknown}", "unknown placeholder {unknown}"},
+		{"orders.{}", "empty placeholder"},
+		{"orders.{meta.}", "unknown placeholder {meta.}"},
+		{"orders.{payload.a..b}", "empty segment"},
+		{"orders.{route", "unclosed '{'"},
+		{"orders.}route", "unexpected '}'"},
+		{"orders.{route}}", "unexpected '}'"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTemplate(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseTemplate(%q) = %v, want error containing %q", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	meta := map[string]string{
+		"route":            "r1",
+		"source_name":      "in",
+		"tenant":           "acme",
+		"X-Region":         "eu",
+		"payload.order.id": "42",
+	}
+	tests := []struct {
+		src, want string
+		static    bool
+	}{
+		{"orders.created", "orders.created", true},
+		{"", "", true},
+		{"orders.{route}", "orders.r1", false},
+		{"${source_name}-{meta.tenant}", "in-acme", false},
+		{"{header.X-Region}.{ payload.order.id }", "eu.42", false},
+	}
+	for _, tt := range tests {
+		tpl, err := ParseTemplate(tt.src)
+		if err != nil {
+			t.Fatalf("ParseTemplate(%q): %v", tt.src, err)
+		}
+		if tpl.Static() != tt.static {
+			t.Errorf("%q: Static() = %v, want %v", tt.src, tpl.Static(), tt.static)
+		}
+		got, err := tpl.Render(meta)
+		if err != nil || got != tt.want {
+			t.Errorf("%q: Render = (%q, %v), want %q", tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestTemplateRenderMissingValue(t *testing.T) {
+	tpl, err := ParseTemplate("orders.{meta.tenant}.{payload.region}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, meta := range []map[string]string{
+		{"payload.region": "eu"},               // thiếu tenant
+		{"tenant": "", "payload.region": "eu"}, // rỗng cũng là lỗi
+		{"tenant": "acme"},                     // thiếu payload.region
+	} {
+		if got, err := tpl.Render(meta); err == nil {
+			t.Errorf("Render(%v) = %q, want error", meta, got)
+		}
+	}
+}
+
+func TestTemplateMatchesAndPayloadPaths(t *testing.T) {
+	tpl, err := ParseTemplate("c.{payload.region}.{route}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tpl.Matches("c.eu.r1") || tpl.Matches("c..r1") || tpl.Matches("d.eu.r1") {
+		t.Error("Matches mismatch")
+	}
+	if got := tpl.PayloadPaths(); len(got) != 1 || got[0] != "payload.region" {
+		t.Errorf("PayloadPaths = %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Query: PromQL cho panel của metric (rate với counter, p99 với histogram).
+func (d *Def) Query() string {
+	by := strings.Join(d.Labels, ", ")
+	switch d.Kind {
+	case Counter:
+		return fmt.Sprintf("sum by (%s) (rate(%s[$__rate_interval]))", by, d.Name)
+	case Histogram:
+		return fmt.Sprintf("histogram_quantile(0.99, sum by (le, %s) (rate(%s_bucket[$__rate_interval])))", by, d.Name)
+	}
+	return fmt.Sprintf("sum by (%s) (%s)", by, d.Name)
+}
+
+func (d *Def) legend() string {
+	parts := make([]string, len(d.Labels))
+	for i, l := range d.Labels {
+		parts[i] = "{{" + l + "}}"
+	}
+	return strings.Join(parts, "/")
+}
+
+// Dashboard sinh JSON dashboard Grafana từ Defs: mỗi Row một hàng, mỗi metric một panel.
+func Dashboard() ([]byte, error) {
+	const w, h = 12, 8
+	ds := map[string]any{"type": "prometheus", "uid": "${datasource}"}
+
+	var panels []map[string]any
+	id, y := 1, 0
+	row, col := "", 0
+	for _, d := range Defs {
+		if d.Row != row {
+			if col != 0 {
+				y += h
+				col = 0
+			}
+			row = d.Row
+			panels = append(panels, map[string]any{
+				"id": id, "type": "row", "title": row, "collapsed": false,
+				"gridPos": map[string]int{"x": 0, "y": y, "w": 24, "h": 1},
+			})
+			id++
+			y++
+		}
+		panels = append(panels, map[string]any{
+			"id":          id,
+			"type":        "timeseries",
+			"title":       d.Title,
+			"description": d.Help,
+			"datasource":  ds,
+			"gridPos":     map[string]int{"x": col * w, "y": y, "w": w, "h": h},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]any{"unit": d.Unit},
+				"overrides": []any{},
+			},
+			"options": map[string]any{
+				"legend":  map[string]any{"displayMode": "table", "placement": "bottom", "showLegend": true},
+				"tooltip": map[string]any{"mode": "multi", "sort": "desc"},
+			},
+			"targets": []map[string]any{{
+				"refId": "A", "datasource": ds, "expr": d.Query(), "legendFormat": d.legend(),
+			}},
+		})
+		id++
+		if col++; col == 24/w {
+			col = 0
+			y += h
+		}
+	}
+
+	dash := map[string]any{
+		"uid":           "validate-yaml-bridge",
+		"title":         "Bridge",
+		"tags":          []string{"bridge", "kafka", "nats", "rabbitmq"},
+		"schemaVersion": 39,
+		"refresh":       "10s",
+		"time":          map[string]string{"from": "now-1h", "to": "now"},
+		"templating": map[string]any{"list": []map[string]any{{
+			"name": "datasource", "label": "Prometheus", "type": "datasource", "query": "prometheus",
+		}}},
+		"panels": panels,
+	}
+	return json.MarshalIndent(dash, "", "  ")
+}
@@ -0,0 +1,59 @@
+// Package metrics xuất metric Prometheus của chính bridge (route, connector, lanes,
+// Kafka lag). Danh sách Defs là nguồn duy nhất: vừa để đăng ký metric, vừa để sinh
+// dashboard Grafana (xem Dashboard).
+package metrics
+
+type Kind int
+
+const (
+	Counter Kind = iota
+	Gauge
+	Histogram
+)
+
+// Def mô tả một metric và panel tương ứng trên dashboard.
+type Def struct {
+	Name   string
+	Help   string
+	Kind   Kind
+	Labels []string
+	Row    string // nhóm panel trên dashboard
+	Title  string // tiêu đề panel
+	Unit   string // đơn vị Grafana (ops, s, short)
+}
+
+const ns = "bridge_"
+
+var (
+	// Theo route (đọc từ RouteCounters của router.Engine lúc scrape)
+	RouteReceived     = &Def{Name: ns + "route_received_total", Help: "Messages received by a route.", Kind: Counter, Labels: []string{"route"}, Row: "Routes", Title: "Received / s", Unit: "ops"}
+	RouteFiltered     = &Def{Name: ns + "route_filtered_total", Help: "Messages dropped by route filters, projection or switch.", Kind: Counter, Labels: []string{"route"}, Row: "Routes", Title: "Filtered / s", Unit: "ops"}
+	RoutePublished    = &Def{Name: ns + "route_published_total", Help: "Successful publishes to route targets (one per target).", Kind: Counter, Labels: []string{"route"}, Row: "Routes", Title: "Published / s", Unit: "ops"}
+	RouteFailed       = &Def{Name: ns + "route_failed_total", Help: "Messages the route failed to deliver (not acked).", Kind: Counter, Labels: []string{"route"}, Row: "Routes", Title: "Failed / s", Unit: "ops"}
+	RouteRetried      = &Def{Name: ns + "route_retried_total", Help: "Publish retries across route targets.", Kind: Counter, Labels: []string{"route"}, Row: "Routes", Title: "Retries / s", Unit: "ops"}
+	RouteDeadLettered = &Def{Name: ns + "route_dead_lettered_total", Help: "Messages sent to the route dead_letter target.", Kind: Counter, Labels: []string{"route"}, Row: "Routes", Title: "Dead-lettered / s", Unit: "ops"}
+	RouteDropped      = &Def{Name: ns + "route_dropped_total", Help: "Messages dropped after expiry or exhausted attempts (mode drop).", Kind: Counter, Labels: []string{"route"}, Row: "Routes", Title: "Dropped / s", Unit: "ops"}
+	RouteRejected     = &Def{Name: ns + "route_rejected_total", Help: "Messages failing schema validation.", Kind: Counter, Labels: []string{"route"}, Row: "Routes", Title: "Rejected / s", Unit: "ops"}
+	LaneDepth         = &Def{Name: ns + "lane_queue_depth", Help: "Messages waiting in publish lanes.", Kind: Gauge, Labels: []string{"route", "target"}, Row: "Routes", Title: "Lane queue depth", Unit: "short"}
+
+	// Theo connector (decorator quanh core.Ingress/core.Egress)
+	IngressReceived = &Def{Name: ns + "ingress_received_total", Help: "Messages received by an ingress.", Kind: Counter, Labels: []string{"connector", "source"}, Row: "Connectors", Title: "Ingress received / s", Unit: "ops"}
+	IngressFiltered = &Def{Name: ns + "ingress_filtered_total", Help: "Ingress messages acked without delivery (filtered by every route).", Kind: Counter, Labels: []string{"connector", "source"}, Row: "Connectors", Title: "Ingress filtered / s", Unit: "ops"}
+	IngressFailed   = &Def{Name: ns + "ingress_failed_total", Help: "Ingress messages not acked (handler error).", Kind: Counter, Labels: []string{"connector", "source"}, Row: "Connectors", Title: "Ingress failed / s", Unit: "ops"}
+	HandleDuration  = &Def{Name: ns + "ingress_handle_duration_seconds", Help: "Time from receive to ack decision.", Kind: Histogram, Labels: []string{"connector", "source"}, Row: "Connectors", Title: "Handle latency p99", Unit: "s"}
+	EgressPublished = &Def{Name: ns + "egress_published_total", Help: "Successful publishes by an egress.", Kind: Counter, Labels: []string{"connector", "target"}, Row: "Connectors", Title: "Egress published / s", Unit: "ops"}
+	EgressFailed    = &Def{Name: ns + "egress_failed_total", Help: "Failed publishes by an egress.", Kind: Counter, Labels: []string{"connector", "target"}, Row: "Connectors", Title: "Egress failed / s", Unit: "ops"}
+	PublishDuration = &Def{Name: ns + "egress_publish_duration_seconds", Help: "Publish latency per egress (including broker confirm).", Kind: Histogram, Labels: []string{"connector", "target"}, Row: "Connectors", Title: "Publish latency p99", Unit: "s"}
+	KafkaLag        = &Def{Name: ns + "kafka_consumer_lag", Help: "Kafka consumer lag (high watermark - offset) per ingress.", Kind: Gauge, Labels: []string{"connector", "source"}, Row: "Connectors", Title: "Kafka consumer lag", Unit: "short"}
+)
+
+// Defs: thứ tự cũng là thứ tự panel trên dashboard.
+var Defs = []*Def{
+	RouteReceived, RouteFiltered, RoutePublished, RouteFailed, RouteRetried,
+	RouteDeadLettered, RouteDropped, RouteRejected, LaneDepth,
+	IngressReceived, IngressFiltered, IngressFailed, HandleDuration,
+	EgressPublished, EgressFailed, PublishDuration, KafkaLag,
+}
+
+// buckets cho histogram latency (giây): 1ms .. ~16s
+var latencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 16}
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/router"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RouteSource: nguồn số liệu theo route (router.Engine).
+type RouteSource interface {
+	Stats() map[string]router.RouteStats
+	LaneDepths() map[string]map[string]int
+}
+
+// Registry giữ metric của bridge; cài core.Observer để gắn vào decorator của connector.
+type Registry struct {
+	reg      *prometheus.Registry
+	counters map[*Def]*prometheus.CounterVec
+	hists    map[*Def]*prometheus.HistogramVec
+	descs    map[*Def]*prometheus.Desc // metric đọc lúc scrape (route, lanes, lag)
+
+	mu     sync.Mutex
+	routes RouteSource
+	lags   []lagSource
+}
+
+type lagSource struct {
+	connector, source string
+	lr                core.LagReporter
+}
+
+// metric theo route/lanes/lag được đọc lúc scrape, không cập nhật trực tiếp
+var scraped = map[*Def]bool{
+	RouteReceived: true, RouteFiltered: true, RoutePublished: true, RouteFailed: true,
+	RouteRetried: true, RouteDeadLettered: true, RouteDropped: true, RouteRejected: true,
+	LaneDepth: true, KafkaLag: true,
+}
+
+func New() *Registry {
+	r := &Registry{
+		reg:      prometheus.NewRegistry(),
+		counters: map[*Def]*prometheus.CounterVec{},
+		hists:    map[*Def]*prometheus.HistogramVec{},
+		descs:    map[*Def]*prometheus.Desc{},
+	}
+	for _, d := range Defs {
+		if scraped[d] {
+			r.descs[d] = prometheus.NewDesc(d.Name, d.Help, d.Labels, nil)
+			continue
+		}
+		switch d.Kind {
+		case Counter:
+			r.counters[d] = prometheus.NewCounterVec(prometheus.CounterOpts{Name: d.Name, Help: d.Help}, d.Labels)
+			r.reg.MustRegister(r.counters[d])
+		case Histogram:
+			r.hists[d] = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: d.Name, Help: d.Help, Buckets: latencyBuckets}, d.Labels)
+			r.reg.MustRegister(r.hists[d])
+		}
+	}
+	r.reg.MustRegister(r, collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return r
+}
+
+// Handler phục vụ /metrics.
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.reg, promhttp.HandlerOpts{})
+}
+
+// Wrap bọc ingress/egress của connector bằng decorator đo metric; ingress có
+// consumer lag (Kafka) được ghi nhận để xuất bridge_kafka_consumer_lag.
+func (r *Registry) Wrap(c core.Connector) core.Connector {
+	r.mu.Lock()
+	for _, in := range c.Ingresses() {
+		if lr, ok := in.(core.LagReporter); ok {
+			r.lags = append(r.lags, lagSource{connector: c.Name(), source: in.SourceName(), lr: lr})
+		}
+	}
+	r.mu.Unlock()
+	return core.WithObserver(c, r)
+}
+
+// WatchRoutes gắn engine để xuất metric theo route và độ sâu lanes.
+func (r *Registry) WatchRoutes(src RouteSource) {
+	r.mu.Lock()
+	r.routes = src
+	r.mu.Unlock()
+}
+
+// ====== core.Observer ======
+
+func (r *Registry) Received(connector, source string) {
+	r.counters[IngressReceived].WithLabelValues(connector, source).Inc()
+}
+
+func (r *Registry) Handled(connector, source string, d time.Duration, err error) {
+	r.hists[HandleDuration].WithLabelValues(connector, source).Observe(d.Seconds())
+	switch {
+	case err == nil:
+	case errors.Is(err, core.ErrFiltered):
+		r.counters[IngressFiltered].WithLabelValues(connector, source).Inc()
+	default:
+		r.counters[IngressFailed].WithLabelValues(connector, source).Inc()
+	}
+}
+
+func (r *Registry) Published(connector, target string, d time.Duration, err error) {
+	r.hists[PublishDuration].WithLabelValues(connector, target).Observe(d.Seconds())
+	if err != nil {
+		r.counters[EgressFailed].WithLabelValues(connector, target).Inc()
+		return
+	}
+	r.counters[EgressPublished].WithLabelValues(connector, target).Inc()
+}
+
+// ====== prometheus.Collector (metric đọc lúc scrape) ======
+
+func (r *Registry) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range r.descs {
+		ch <- d
+	}
+}
+
+func (r *Registry) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	routes, lags := r.routes, r.lags
+	r.mu.Unlock()
+
+	if routes != nil {
+		for name, st := range routes.Stats() {
+			for d, v := range map[*Def]uint64{
+				RouteReceived:     st.Received,
+				RouteFiltered:     st.Filtered,
+				RoutePublished:    st.Published,
+				RouteFailed:       st.Failed,
+				RouteRetried:      st.Retried,
+				RouteDeadLettered: st.DeadLettered,
+				RouteDropped:      st.Dropped,
+				RouteRejected:     st.Rejected,
+			} {
+				ch <- prometheus.MustNewConstMetric(r.descs[d], prometheus.CounterValue, float64(v), name)
+			}
+		}
+		for name, targets := range routes.LaneDepths() {
+			for tgt, n := range targets {
+				ch <- prometheus.MustNewConstMetric(r.descs[LaneDepth], prometheus.GaugeValue, float64(n), name, tgt)
+			}
+		}
+	}
+	for _, l := range lags {
+		if lag := l.lr.Lag(); lag >= 0 {
+			ch <- prometheus.MustNewConstMetric(r.descs[KafkaLag], prometheus.GaugeValue, float64(lag), l.connector, l.source)
+		}
+	}
+}
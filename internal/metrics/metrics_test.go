@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/router"
+)
+
+// fakeConnector: một ingress (có lag) và một egress; test gọi handler qua ingress.
+type fakeConnector struct {
+	in  *fakeIngress
+	out *fakeEgress
+}
+
+func (c *fakeConnector) Name() string              { return "k" }
+func (c *fakeConnector) Open() error               { return nil }
+func (c *fakeConnector) Close() error              { return nil }
+func (c *fakeConnector) Ingresses() []core.Ingress { return []core.Ingress{c.in} }
+func (c *fakeConnector) Egresses() []core.Egress   { return []core.Egress{c.out} }
+
+type fakeIngress struct{ h core.Handler }
+
+func (i *fakeIngress) SourceName() string                            { return "orders" }
+func (i *fakeIngress) Start(_ context.Context, h core.Handler) error { i.h = h; return nil }
+func (i *fakeIngress) Stop(context.Context) error                    { return nil }
+func (i *fakeIngress) Lag() int64                                    { return 7 }
+
+type fakeEgress struct{ err error }
+
+func (e *fakeEgress) TargetName() string { return "out" }
+func (e *fakeEgress) Close() error       { return nil }
+func (e *fakeEgress) Publish(context.Context, []byte, map[string]string) error {
+	return e.err
+}
+
+type fakeRoutes struct{}
+
+func (fakeRoutes) Stats() map[string]router.RouteStats {
+	return map[string]router.RouteStats{"r": {Received: 3, Published: 2, Rejected: 1}}
+}
+func (fakeRoutes) LaneDepths() map[string]map[string]int {
+	return map[string]map[string]int{"r": {"k/out": 4}}
+}
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestRegistryScrape(t *testing.T) {
+	r := New()
+	fc := &fakeConnector{in: &fakeIngress{}, out: &fakeEgress{}}
+	c := r.Wrap(fc)
+	r.WatchRoutes(fakeRoutes{})
+
+	ctx := context.Background()
+	if err := c.Ingresses()[0].Start(ctx, func(_ context.Context, msg []byte, _ map[string]string) error {
+		switch string(msg) {
+		case "filtered":
+			return core.ErrFiltered
+		case "bad":
+			return errors.New("boom")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []string{"ok", "ok", "filtered", "bad"} {
+		fc.in.h(ctx, []byte(m), nil)
+	}
+	eg := c.Egresses()[0]
+	eg.Publish(ctx, nil, nil)
+	fc.out.err = errors.New("nack")
+	eg.Publish(ctx, nil, nil)
+
+	body := scrape(t, r)
+	for _, want := range []string{
+		`bridge_ingress_received_total{connector="k",source="orders"} 4`,
+		`bridge_ingress_filtered_total{connector="k",source="orders"} 1`,
+		`bridge_ingress_failed_total{connector="k",source="orders"} 1`,
+		`bridge_ingress_handle_duration_seconds_count{connector="k",source="orders"} 4`,
+		`bridge_egress_published_total{connector="k",target="out"} 1`,
+		`bridge_egress_failed_total{connector="k",target="out"} 1`,
+		`bridge_egress_publish_duration_seconds_count{connector="k",target="out"} 2`,
+		`bridge_route_received_total{route="r"} 3`,
+		`bridge_route_published_total{route="r"} 2`,
+		`bridge_route_rejected_total{route="r"} 1`,
+		`bridge_lane_queue_depth{route="r",target="k/out"} 4`,
+		`bridge_kafka_consumer_lag{connector="k",source="orders"} 7`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape missing %s", want)
+		}
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	b, err := Dashboard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dash struct {
+		Panels []struct {
+			Type    string `json:"type"`
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(b, &dash); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []string
+	var exprs []string
+	for _, p := range dash.Panels {
+		if p.Type == "row" {
+			rows = append(rows, p.Title)
+			continue
+		}
+		exprs = append(exprs, p.Targets[0].Expr)
+	}
+	if strings.Join(rows, ",") != "Routes,Connectors" {
+		t.Fatalf("rows = %v", rows)
+	}
+	if len(exprs) != len(Defs) {
+		t.Fatalf("%d panels, want one per Def (%d)", len(exprs), len(Defs))
+	}
+	for i, d := range Defs {
+		if exprs[i] != d.Query() {
+			t.Errorf("panel %d expr = %q, want %q", i, exprs[i], d.Query())
+		}
+	}
+}
+
+func TestDefQuery(t *testing.T) {
+	tests := []struct {
+		d    *Def
+		want string
+	}{
+		{RouteReceived, "sum by (route) (rate(bridge_route_received_total[$__rate_interval]))"},
+		{HandleDuration, "histogram_quantile(0.99, sum by (le, connector, source) (rate(bridge_ingress_handle_duration_seconds_bucket[$__rate_interval])))"},
+		{LaneDepth, "sum by (route, target) (bridge_lane_queue_depth)"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.Query(); got != tt.want {
+			t.Errorf("%s: Query() = %q, want %q", tt.d.Name, got, tt.want)
+		}
+	}
+}
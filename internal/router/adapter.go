@@ -4,11 +4,19 @@ package router
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	core "github.com/cuongceg/validate_yaml/internal/core"
 )
 
+// meta do adapter/engine gắn chỉ dùng trong route (filter, template), không gửi thành header;
+// "payload.*" là giá trị {payload.*} cho template của egress.
+func init() {
+	core.InternalMeta("source_name", "source_connector", "size", "route")
+	core.InternalMetaPrefix("payload.")
+}
+
 type busAdapter struct {
 	name      string
 	conn      core.Connector
@@ -45,6 +53,18 @@ func (b *busAdapter) Subscribe(ctx context.Context, sourceName string, h func(co
 			Value: payload,        // giữ nguyên bytes
 			Meta:  toAnyMap(meta), // map[string]string -> map[string]any
 		}
+		if msg.Meta == nil {
+			msg.Meta = map[string]any{}
+		}
+		msg.Meta["source_name"] = sourceName
+		msg.Meta["source_connector"] = b.name
+		// các trường số dùng trong filter (meta.size, meta.createdAtMs)
+		msg.Meta["size"] = int64(len(payload))
+		if s, ok := msg.Meta["createdAtMs"].(string); ok {
+			if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+				msg.Meta["createdAtMs"] = ms
+			}
+		}
 		return h(c, msg)
 	})
 }
@@ -107,6 +127,8 @@ func toStringMap(m map[string]any) map[string]string {
 		switch vv := v.(type) {
 		case string:
 			out[k] = vv
+		case float64:
+			out[k] = strconv.FormatFloat(vv, 'f', -1, 64)
 		case fmt.Stringer:
 			out[k] = vv.String()
 		default:
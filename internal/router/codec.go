@@ -1,12 +1,16 @@
 package router
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"strings"
 
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 func newOf[T any]() T {
@@ -24,23 +28,23 @@ func NewProtoCodec[T proto.Message]() *ProtoCodec[T] { return &ProtoCodec[T]{} }
 func (c *ProtoCodec[T]) ContentType() string { return "application/x-protobuf" }
 
 func (c *ProtoCodec[T]) Decode(b []byte) (map[string]any, any, error) {
-	var zeroMap map[string]any
 	msg := newOf[T]()
 	if err := proto.Unmarshal(b, msg); err != nil {
 		return nil, nil, err
 	}
-	jb, err := protojson.Marshal(msg)
+	obj, err := protoToMap(msg.ProtoReflect())
 	if err != nil {
 		return nil, nil, err
 	}
-	if err := json.Unmarshal(jb, &zeroMap); err != nil {
-		return nil, nil, err
-	}
-	return zeroMap, msg, nil
+	return obj, msg, nil
 }
 
 func (c *ProtoCodec[T]) Encode(obj map[string]any, orig any) ([]byte, error) {
+	// orig nil khi transcode từ codec khác sang protobuf
 	msg, ok := orig.(T)
+	if orig == nil {
+		msg, ok = newOf[T](), true
+	}
 	if !ok {
 		return nil, fmt.Errorf("ProtoCodec: unexpected domain type (got %T)", orig)
 	}
@@ -48,8 +52,104 @@ func (c *ProtoCodec[T]) Encode(obj map[string]any, orig any) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := protojson.Unmarshal(jb, msg); err != nil {
+	// DiscardUnknown: projection có thể chép meta.<key> vào gốc payload
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(jb, msg); err != nil {
 		return nil, err
 	}
 	return proto.Marshal(msg)
 }
+
+// protoToMap chuyển message sang map theo tên JSON (giống protojson) nhưng giữ
+// kiểu số nguyên (int64 không bị thành string) để CEL so sánh được payload.age > 16.
+func protoToMap(m protoreflect.Message) (map[string]any, error) {
+	out := make(map[string]any, m.Descriptor().Fields().Len())
+	var rerr error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		val, err := protoFieldValue(fd, v)
+		if err != nil {
+			rerr = fmt.Errorf("field %s: %w", fd.FullName(), err)
+			return false
+		}
+		out[fd.JSONName()] = val
+		return true
+	})
+	return out, rerr
+}
+
+func protoFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (any, error) {
+	switch {
+	case fd.IsList():
+		l := v.List()
+		items := make([]any, 0, l.Len())
+		for i := 0; i < l.Len(); i++ {
+			item, err := protoSingular(fd, l.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case fd.IsMap():
+		out := map[string]any{}
+		var rerr error
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			item, err := protoSingular(fd.MapValue(), mv)
+			if err != nil {
+				rerr = err
+				return false
+			}
+			out[k.String()] = item
+			return true
+		})
+		return out, rerr
+	}
+	return protoSingular(fd, v)
+}
+
+func protoSingular(fd protoreflect.FieldDescriptor, v protoreflect.Value) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		// well-known types (Timestamp, Struct, ...) có format JSON riêng
+		if strings.HasPrefix(string(fd.Message().FullName()), "google.protobuf.") {
+			jb, err := protojson.Marshal(v.Message().Interface())
+			if err != nil {
+				return nil, err
+			}
+			var out any
+			if err := json.Unmarshal(jb, &out); err != nil {
+				return nil, err
+			}
+			return out, nil
+		}
+		return protoToMap(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return int64(v.Enum()), nil
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+	case protoreflect.BoolKind:
+		return v.Bool(), nil
+	case protoreflect.StringKind:
+		return v.String(), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int(), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint(), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return "NaN", nil
+		case math.IsInf(f, 1):
+			return "Infinity", nil
+		case math.IsInf(f, -1):
+			return "-Infinity", nil
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported kind %s", fd.Kind())
+}
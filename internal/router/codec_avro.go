@@ -0,0 +1,170 @@
+package router
+
+import (
+	"fmt"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/hamba/avro/v2"
+)
+
+// AvroCodec: payload là Avro binary (không header) theo một record schema cố định.
+type AvroCodec struct {
+	schema avro.Schema
+}
+
+func NewAvroCodec(schema avro.Schema) (*AvroCodec, error) {
+	if schema.Type() != avro.Record {
+		return nil, fmt.Errorf("AvroCodec: schema must be a record (got %s)", schema.Type())
+	}
+	return &AvroCodec{schema: schema}, nil
+}
+
+func (c *AvroCodec) ContentType() string { return "avro/binary" }
+
+func (c *AvroCodec) Decode(b []byte) (map[string]any, any, error) {
+	var obj map[string]any
+	if err := avro.Unmarshal(c.schema, b, &obj); err != nil {
+		return nil, nil, fmt.Errorf("AvroCodec: %w", err)
+	}
+	return obj, nil, nil
+}
+
+// Encode ép kiểu số theo schema (int/long/float/double) vì obj có thể đến từ
+// JSON/CEL (int64/float64) trước khi marshal.
+func (c *AvroCodec) Encode(obj map[string]any, _ any) ([]byte, error) {
+	v, err := coerceAvro(c.schema, obj)
+	if err != nil {
+		return nil, fmt.Errorf("AvroCodec: %w", err)
+	}
+	return avro.Marshal(c.schema, v)
+}
+
+func coerceAvro(s avro.Schema, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch sc := s.(type) {
+	case *avro.RecordSchema:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("record %s: expected object, got %T", sc.FullName(), v)
+		}
+		out := make(map[string]any, len(m))
+		for _, f := range sc.Fields() {
+			fv, ok := m[f.Name()]
+			if !ok {
+				continue
+			}
+			cv, err := coerceAvro(f.Type(), fv)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%w", f.Name(), err)
+			}
+			out[f.Name()] = cv
+		}
+		return out, nil
+	case *avro.ArraySchema:
+		items, ok := v.([]any)
+		if !ok {
+			return v, nil
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			cv, err := coerceAvro(sc.Items(), item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = cv
+		}
+		return out, nil
+	case *avro.MapSchema:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v, nil
+		}
+		out := make(map[string]any, len(m))
+		for k, item := range m {
+			cv, err := coerceAvro(sc.Values(), item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = cv
+		}
+		return out, nil
+	case *avro.UnionSchema:
+		// nullable union [null, T]: ép theo T
+		if sc.Nullable() {
+			for _, t := range sc.Types() {
+				if t.Type() != avro.Null {
+					return coerceAvro(t, v)
+				}
+			}
+		}
+		return v, nil
+	case *avro.PrimitiveSchema:
+		switch sc.Type() {
+		case avro.Int:
+			if n, ok := toInt64(v); ok {
+				return int(n), nil
+			}
+		case avro.Long:
+			if n, ok := toInt64(v); ok {
+				return n, nil
+			}
+		case avro.Float:
+			if f, ok := toFloat64(v); ok {
+				return float32(f), nil
+			}
+		case avro.Double:
+			if f, ok := toFloat64(v); ok {
+				return f, nil
+			}
+		}
+	}
+	return v, nil
+}
+
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), true
+	case float64:
+		if n == float64(int64(n)) {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+func toFloat64(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func init() {
+	RegisterCodec("avro", func(spec cfg.Codec) (PayloadCodec, error) {
+		path, _ := spec.Params["schema_file"].(string)
+		if path == "" {
+			return nil, fmt.Errorf("avro codec requires params.schema_file")
+		}
+		schema, err := avro.ParseFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("parse avro schema %s: %w", path, err)
+		}
+		return NewAvroCodec(schema)
+	})
+}
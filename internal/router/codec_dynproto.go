@@ -0,0 +1,104 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// DynamicProtoCodec decode/encode message protobuf theo descriptor nạp lúc chạy
+// (protoc --descriptor_set_out), không cần type Go sinh sẵn như ProtoCodec[T].
+type DynamicProtoCodec struct {
+	desc  protoreflect.MessageDescriptor
+	types *dynamicpb.Types
+}
+
+// LoadDescriptorSet đọc FileDescriptorSet (nên build với --include_imports).
+func LoadDescriptorSet(path string) (*protoregistry.Files, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("parse descriptor set %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("descriptor set %s: %w", path, err)
+	}
+	return files, nil
+}
+
+func NewDynamicProtoCodec(files *protoregistry.Files, message string) (*DynamicProtoCodec, error) {
+	d, err := files.FindDescriptorByName(protoreflect.FullName(message))
+	if err != nil {
+		return nil, fmt.Errorf("message %q: %w", message, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message", message)
+	}
+	return &DynamicProtoCodec{desc: md, types: dynamicpb.NewTypes(files)}, nil
+}
+
+func (c *DynamicProtoCodec) ContentType() string { return "application/x-protobuf" }
+
+func (c *DynamicProtoCodec) Decode(b []byte) (map[string]any, any, error) {
+	msg := dynamicpb.NewMessage(c.desc)
+	if err := (proto.UnmarshalOptions{Resolver: c.types}).Unmarshal(b, msg); err != nil {
+		return nil, nil, err
+	}
+	obj, err := protoToMap(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return obj, msg, nil
+}
+
+func (c *DynamicProtoCodec) Encode(obj map[string]any, orig any) ([]byte, error) {
+	msg, ok := orig.(*dynamicpb.Message)
+	if orig == nil || (ok && msg.Descriptor().FullName() != c.desc.FullName()) {
+		msg, ok = dynamicpb.NewMessage(c.desc), true
+	}
+	if !ok {
+		return nil, fmt.Errorf("DynamicProtoCodec: unexpected domain type (got %T)", orig)
+	}
+	jb, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	// DiscardUnknown: bỏ trường ngoài message (vd meta.<key> do projection thêm)
+	if err := (protojson.UnmarshalOptions{Resolver: c.types, DiscardUnknown: true}).Unmarshal(jb, msg); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+func init() {
+	// codecs:
+	//   - name: telemetry
+	//     type: protobuf
+	//     params: { descriptor_set: schemas/telemetry.pb, message: acme.v1.Telemetry }
+	RegisterCodec("protobuf", func(spec cfg.Codec) (PayloadCodec, error) {
+		path, _ := spec.Params["descriptor_set"].(string)
+		message, _ := spec.Params["message"].(string)
+		if path == "" || message == "" {
+			return nil, fmt.Errorf("protobuf codec requires params.descriptor_set and params.message")
+		}
+		files, err := LoadDescriptorSet(path)
+		if err != nil {
+			return nil, err
+		}
+		return NewDynamicProtoCodec(files, message)
+	})
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+)
+
+// JSONCodec: payload là JSON object. Số nguyên giữ kiểu int64 (không mất chính xác),
+// số thực là float64; filter/projection dùng cùng cú pháp path payload.a.b.
+type JSONCodec struct{}
+
+func NewJSONCodec() *JSONCodec { return &JSONCodec{} }
+
+func (c *JSONCodec) ContentType() string { return "application/json" }
+
+func (c *JSONCodec) Decode(b []byte) (map[string]any, any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, nil, fmt.Errorf("JSONCodec: %w", err)
+	}
+	obj, ok := normalizeJSON(v).(map[string]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("JSONCodec: payload must be a JSON object (got %T)", v)
+	}
+	return obj, nil, nil
+}
+
+func (c *JSONCodec) Encode(obj map[string]any, _ any) ([]byte, error) {
+	return json.Marshal(obj)
+}
+
+func normalizeJSON(v any) any {
+	switch vv := v.(type) {
+	case json.Number:
+		if i, err := vv.Int64(); err == nil {
+			return i
+		}
+		f, _ := vv.Float64()
+		return f
+	case map[string]any:
+		for k, item := range vv {
+			vv[k] = normalizeJSON(item)
+		}
+		return vv
+	case []any:
+		for i, item := range vv {
+			vv[i] = normalizeJSON(item)
+		}
+		return vv
+	}
+	return v
+}
+
+func init() {
+	RegisterCodec("json", func(cfg.Codec) (PayloadCodec, error) { return NewJSONCodec(), nil })
+}
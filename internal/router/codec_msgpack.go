@@ -0,0 +1,64 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackCodec: payload là MessagePack map; số nguyên -> int64/uint64, số thực -> float64.
+type MsgpackCodec struct{}
+
+func NewMsgpackCodec() *MsgpackCodec { return &MsgpackCodec{} }
+
+func (c *MsgpackCodec) ContentType() string { return "application/msgpack" }
+
+func (c *MsgpackCodec) Decode(b []byte) (map[string]any, any, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	dec.UseLooseInterfaceDecoding(true)
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+	v, err := dec.DecodeInterfaceLoose()
+	if err != nil {
+		return nil, nil, fmt.Errorf("MsgpackCodec: %w", err)
+	}
+	obj, ok := stringKeys(v).(map[string]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("MsgpackCodec: payload must be a map (got %T)", v)
+	}
+	return obj, nil, nil
+}
+
+func (c *MsgpackCodec) Encode(obj map[string]any, _ any) ([]byte, error) {
+	return msgpack.Marshal(obj)
+}
+
+// stringKeys chuyển map[any]any (key không phải string) về map[string]any.
+func stringKeys(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		for k, item := range vv {
+			vv[k] = stringKeys(item)
+		}
+		return vv
+	case map[any]any:
+		out := make(map[string]any, len(vv))
+		for k, item := range vv {
+			out[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return out
+	case []any:
+		for i, item := range vv {
+			vv[i] = stringKeys(item)
+		}
+		return vv
+	}
+	return v
+}
+
+func init() {
+	RegisterCodec("msgpack", func(cfg.Codec) (PayloadCodec, error) { return NewMsgpackCodec(), nil })
+}
@@ -0,0 +1,38 @@
+package router
+
+import (
+	"fmt"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+)
+
+// RawCodec không decode: route chỉ forward nguyên bytes (filter chỉ dùng được meta).
+type RawCodec struct{}
+
+func NewRawCodec() *RawCodec { return &RawCodec{} }
+
+func (c *RawCodec) ContentType() string { return "application/octet-stream" }
+
+// Passthrough báo cho router bỏ qua bước Decode.
+func (c *RawCodec) Passthrough() bool { return true }
+
+func (c *RawCodec) Decode(b []byte) (map[string]any, any, error) {
+	return nil, b, nil
+}
+
+func (c *RawCodec) Encode(obj map[string]any, orig any) ([]byte, error) {
+	if b, ok := orig.([]byte); ok && obj == nil {
+		return b, nil
+	}
+	return nil, fmt.Errorf("RawCodec: cannot encode structured payload")
+}
+
+// passthrough: codec không cần decode payload.
+func passthrough(c PayloadCodec) bool {
+	p, ok := c.(interface{ Passthrough() bool })
+	return ok && p.Passthrough()
+}
+
+func init() {
+	RegisterCodec("raw", func(cfg.Codec) (PayloadCodec, error) { return NewRawCodec(), nil })
+}
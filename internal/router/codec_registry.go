@@ -0,0 +1,74 @@
+package router
+
+import (
+	"fmt"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+)
+
+// CodecFactory tạo codec từ một mục trong codecs: (type + params).
+type CodecFactory func(spec cfg.Codec) (PayloadCodec, error)
+
+var codecFactories = map[string]CodecFactory{}
+
+// RegisterCodec đăng ký codec theo type. Codec không cần params có thể được
+// tham chiếu trực tiếp bằng type (vd: ingress.codec: json).
+func RegisterCodec(kind string, f CodecFactory) {
+	codecFactories[kind] = f
+}
+
+func BuildCodec(spec cfg.Codec) (PayloadCodec, error) {
+	f, ok := codecFactories[spec.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec type: %s", spec.Type)
+	}
+	return f(spec)
+}
+
+// BuildCodecs dựng mọi codec khai báo trong codecs: và mọi codec được ingress/egress
+// (hoặc route) tham chiếu theo type; trả map theo tên để gán vào Engine.Codecs.
+func BuildCodecs(uc *cfg.UserConfig) (map[string]PayloadCodec, error) {
+	out := make(map[string]PayloadCodec)
+	for _, spec := range uc.Codecs {
+		c, err := BuildCodec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("codec %q: %w", spec.Name, err)
+		}
+		out[spec.Name] = c
+	}
+	ref := func(where, name string) error {
+		if name == "" {
+			return nil
+		}
+		if _, ok := out[name]; ok {
+			return nil
+		}
+		c, err := BuildCodec(cfg.Codec{Name: name, Type: name})
+		if err != nil {
+			return fmt.Errorf("%s: codec %q is neither declared in codecs nor a builtin type", where, name)
+		}
+		out[name] = c
+		return nil
+	}
+	for _, c := range uc.Connectors {
+		for _, in := range c.Ingress {
+			if err := ref(fmt.Sprintf("connector %q ingress %q", c.Name, in.SourceName), in.Codec); err != nil {
+				return nil, err
+			}
+		}
+		for _, eg := range c.Egress {
+			if err := ref(fmt.Sprintf("connector %q egress %q", c.Name, eg.Name), eg.Codec); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, r := range uc.Routes {
+		if err := ref(fmt.Sprintf("route %q", r.Name), r.Codec); err != nil {
+			return nil, err
+		}
+		if err := ref(fmt.Sprintf("route %q output", r.Name), r.OutputCodec); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
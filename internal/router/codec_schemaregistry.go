@@ -0,0 +1,451 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/schemaregistry"
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// SchemaRegistryCodec đọc/ghi payload theo wire format của Confluent
+// (magic byte + schema ID [+ message indexes với protobuf]). Decode lấy schema
+// theo ID từ registry; Encode dùng schema đầu ra (schema_file hoặc latest của
+// subject), nếu không có thì dùng lại schema ID của message nguồn.
+type SchemaRegistryCodec struct {
+	client  schemaregistry.Client
+	timeout time.Duration
+
+	// đầu ra
+	format       string // AVRO | PROTOBUF | JSON
+	subject      string
+	schemaFile   string
+	message      string // protobuf: full name message đầu ra (mặc định message đầu tiên)
+	autoRegister bool
+
+	mu      sync.Mutex
+	schemas map[int]*srSchema
+	out     *srDomain // schema đầu ra đã resolve
+}
+
+type SchemaRegistryOptions struct {
+	Format       string
+	Subject      string
+	SchemaFile   string
+	Message      string
+	AutoRegister bool
+	Timeout      time.Duration
+}
+
+func NewSchemaRegistryCodec(client schemaregistry.Client, opts SchemaRegistryOptions) *SchemaRegistryCodec {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &SchemaRegistryCodec{
+		client:       client,
+		timeout:      opts.Timeout,
+		format:       strings.ToUpper(opts.Format),
+		subject:      opts.Subject,
+		schemaFile:   opts.SchemaFile,
+		message:      opts.Message,
+		autoRegister: opts.AutoRegister,
+		schemas:      map[int]*srSchema{},
+	}
+}
+
+// srSchema: schema đã biên dịch theo ID.
+type srSchema struct {
+	typ   string
+	avro  avro.Schema
+	proto protoreflect.FileDescriptor
+	types *dynamicpb.Types
+}
+
+// srDomain là domain trả từ Decode: schema ID (+ message indexes) của message nguồn.
+type srDomain struct {
+	id  int
+	idx []int
+}
+
+func (c *SchemaRegistryCodec) ContentType() string {
+	switch c.format {
+	case schemaregistry.TypeProtobuf:
+		return "application/vnd.confluent.protobuf"
+	case schemaregistry.TypeJSON:
+		return "application/vnd.confluent.json"
+	}
+	return "application/vnd.confluent.avro"
+}
+
+func (c *SchemaRegistryCodec) Decode(b []byte) (map[string]any, any, error) {
+	id, rest, err := schemaregistry.ParseHeader(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := c.schema(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	dom := &srDomain{id: id}
+	switch s.typ {
+	case schemaregistry.TypeAvro:
+		var obj map[string]any
+		if err := avro.Unmarshal(s.avro, rest, &obj); err != nil {
+			return nil, nil, fmt.Errorf("schema %d: %w", id, err)
+		}
+		return obj, dom, nil
+	case schemaregistry.TypeProtobuf:
+		idx, n, err := schemaregistry.ReadMessageIndexes(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		md, err := s.messageAt(idx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("schema %d: %w", id, err)
+		}
+		msg := dynamicpb.NewMessage(md)
+		if err := (proto.UnmarshalOptions{Resolver: s.types}).Unmarshal(rest[n:], msg); err != nil {
+			return nil, nil, fmt.Errorf("schema %d: %w", id, err)
+		}
+		obj, err := protoToMap(msg)
+		if err != nil {
+			return nil, nil, err
+		}
+		dom.idx = idx
+		return obj, dom, nil
+	default:
+		obj, _, err := NewJSONCodec().Decode(rest)
+		if err != nil {
+			return nil, nil, fmt.Errorf("schema %d: %w", id, err)
+		}
+		return obj, dom, nil
+	}
+}
+
+func (c *SchemaRegistryCodec) Encode(obj map[string]any, orig any) ([]byte, error) {
+	dom, err := c.output()
+	if err != nil {
+		return nil, err
+	}
+	if dom == nil {
+		src, ok := orig.(*srDomain)
+		if !ok {
+			return nil, fmt.Errorf("SchemaRegistryCodec: no output schema (set subject or schema_file)")
+		}
+		dom = src
+	}
+	s, err := c.schema(dom.id)
+	if err != nil {
+		return nil, err
+	}
+	out := schemaregistry.AppendHeader(make([]byte, 0, 64), dom.id)
+	switch s.typ {
+	case schemaregistry.TypeAvro:
+		v, err := coerceAvro(s.avro, obj)
+		if err != nil {
+			return nil, fmt.Errorf("schema %d: %w", dom.id, err)
+		}
+		b, err := avro.Marshal(s.avro, v)
+		if err != nil {
+			return nil, fmt.Errorf("schema %d: %w", dom.id, err)
+		}
+		return append(out, b...), nil
+	case schemaregistry.TypeProtobuf:
+		md, err := s.messageAt(dom.idx)
+		if err != nil {
+			return nil, fmt.Errorf("schema %d: %w", dom.id, err)
+		}
+		jb, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		msg := dynamicpb.NewMessage(md)
+		if err := (protojson.UnmarshalOptions{Resolver: s.types, DiscardUnknown: true}).Unmarshal(jb, msg); err != nil {
+			return nil, fmt.Errorf("schema %d: %w", dom.id, err)
+		}
+		b, err := proto.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		out = schemaregistry.AppendMessageIndexes(out, dom.idx)
+		return append(out, b...), nil
+	default:
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		return append(out, b...), nil
+	}
+}
+
+// output resolve (một lần) schema ID đầu ra; nil nếu codec không cấu hình đầu ra.
+// Lỗi không được cache để lần publish sau thử lại (registry có thể tạm lỗi).
+func (c *SchemaRegistryCodec) output() (*srDomain, error) {
+	if c.schemaFile == "" && c.subject == "" {
+		return nil, nil
+	}
+	c.mu.Lock()
+	out := c.out
+	c.mu.Unlock()
+	if out != nil {
+		return out, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	var id int
+	if c.schemaFile != "" {
+		text, err := os.ReadFile(c.schemaFile)
+		if err != nil {
+			return nil, err
+		}
+		s := schemaregistry.Schema{Type: c.format, Schema: string(text)}
+		if c.autoRegister {
+			id, err = c.client.Register(ctx, c.subject, s)
+		} else {
+			id, err = c.client.Lookup(ctx, c.subject, s)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("subject %q: %w", c.subject, err)
+		}
+	} else {
+		s, err := c.client.Version(ctx, c.subject, 0)
+		if err != nil {
+			return nil, fmt.Errorf("subject %q: %w", c.subject, err)
+		}
+		id = s.ID
+	}
+
+	out = &srDomain{id: id}
+	s, err := c.schema(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.typ == schemaregistry.TypeProtobuf {
+		md, err := s.findMessage(c.message)
+		if err != nil {
+			return nil, fmt.Errorf("schema %d: %w", id, err)
+		}
+		out.idx = messageIndexes(md)
+	}
+	c.mu.Lock()
+	c.out = out
+	c.mu.Unlock()
+	return out, nil
+}
+
+// schema lấy (và cache) schema đã biên dịch theo ID.
+func (c *SchemaRegistryCodec) schema(id int) (*srSchema, error) {
+	c.mu.Lock()
+	s, ok := c.schemas[id]
+	c.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	raw, err := c.client.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	s, err = c.compile(ctx, id, raw)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	c.mu.Lock()
+	c.schemas[id] = s
+	c.mu.Unlock()
+	return s, nil
+}
+
+func (c *SchemaRegistryCodec) compile(ctx context.Context, id int, raw *schemaregistry.Schema) (*srSchema, error) {
+	s := &srSchema{typ: raw.SchemaType()}
+	switch s.typ {
+	case schemaregistry.TypeAvro:
+		cache := &avro.SchemaCache{}
+		// named types tham chiếu (references) phải parse trước vào cùng cache
+		var parseRefs func(refs []schemaregistry.Reference) error
+		parseRefs = func(refs []schemaregistry.Reference) error {
+			for _, ref := range refs {
+				rs, err := c.client.Version(ctx, ref.Subject, ref.Version)
+				if err != nil {
+					return fmt.Errorf("reference %s: %w", ref.Name, err)
+				}
+				if err := parseRefs(rs.References); err != nil {
+					return err
+				}
+				if _, err := avro.ParseWithCache(rs.Schema, "", cache); err != nil {
+					return fmt.Errorf("reference %s: %w", ref.Name, err)
+				}
+			}
+			return nil
+		}
+		if err := parseRefs(raw.References); err != nil {
+			return nil, err
+		}
+		sch, err := avro.ParseWithCache(raw.Schema, "", cache)
+		if err != nil {
+			return nil, err
+		}
+		s.avro = sch
+	case schemaregistry.TypeProtobuf:
+		// import trong .proto -> references (name = đường dẫn import)
+		srcs := map[string]string{}
+		var addRefs func(refs []schemaregistry.Reference) error
+		addRefs = func(refs []schemaregistry.Reference) error {
+			for _, ref := range refs {
+				if _, ok := srcs[ref.Name]; ok {
+					continue
+				}
+				rs, err := c.client.Version(ctx, ref.Subject, ref.Version)
+				if err != nil {
+					return fmt.Errorf("reference %s: %w", ref.Name, err)
+				}
+				srcs[ref.Name] = rs.Schema
+				if err := addRefs(rs.References); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := addRefs(raw.References); err != nil {
+			return nil, err
+		}
+		root := fmt.Sprintf("schema-registry/%d.proto", id)
+		srcs[root] = raw.Schema
+		comp := protocompile.Compiler{
+			Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+				Accessor: protocompile.SourceAccessorFromMap(srcs),
+			}),
+		}
+		compiled, err := comp.Compile(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		files := new(protoregistry.Files)
+		if err := registerFile(files, compiled[0]); err != nil {
+			return nil, err
+		}
+		s.proto = compiled[0]
+		s.types = dynamicpb.NewTypes(files)
+	case schemaregistry.TypeJSON:
+	default:
+		return nil, fmt.Errorf("unsupported schema type %q", raw.Type)
+	}
+	return s, nil
+}
+
+// registerFile đăng ký file và mọi import (đệ quy) để resolve Any/extension.
+func registerFile(files *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(fd)
+}
+
+func (s *srSchema) messageAt(idx []int) (protoreflect.MessageDescriptor, error) {
+	if len(idx) == 0 {
+		idx = []int{0}
+	}
+	msgs := s.proto.Messages()
+	var md protoreflect.MessageDescriptor
+	for _, i := range idx {
+		if i < 0 || i >= msgs.Len() {
+			return nil, fmt.Errorf("message index %v out of range", idx)
+		}
+		md = msgs.Get(i)
+		msgs = md.Messages()
+	}
+	return md, nil
+}
+
+func (s *srSchema) findMessage(name string) (protoreflect.MessageDescriptor, error) {
+	if name == "" {
+		return s.messageAt(nil)
+	}
+	if mt, err := s.types.FindMessageByName(protoreflect.FullName(name)); err == nil {
+		return mt.Descriptor(), nil
+	}
+	return nil, fmt.Errorf("message %q not found in %s", name, s.proto.Path())
+}
+
+// messageIndexes: đường dẫn index của message trong file (top-level -> nested).
+func messageIndexes(md protoreflect.MessageDescriptor) []int {
+	var idx []int
+	for d := protoreflect.Descriptor(md); ; d = d.Parent() {
+		m, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			break
+		}
+		idx = append([]int{m.Index()}, idx...)
+	}
+	return idx
+}
+
+func init() {
+	// codecs:
+	//   - name: orders-sr
+	//     type: schema_registry
+	//     params:
+	//       url: http://schema-registry:8081   # hoặc file: schemas/registry.json (offline)
+	//       format: avro                       # avro | protobuf | json (schema đầu ra)
+	//       subject: orders-value
+	//       schema_file: schemas/order.avsc
+	//       auto_register: true
+	RegisterCodec("schema_registry", func(spec cfg.Codec) (PayloadCodec, error) {
+		str := func(k string) string { s, _ := spec.Params[k].(string); return s }
+		var timeout time.Duration
+		if ms, ok := spec.Params["timeout_ms"].(int); ok {
+			timeout = time.Duration(ms) * time.Millisecond
+		}
+		var client schemaregistry.Client
+		switch {
+		case str("file") != "":
+			m, err := schemaregistry.OpenFile(str("file"))
+			if err != nil {
+				return nil, err
+			}
+			client = m
+		case str("url") != "":
+			hc, err := schemaregistry.NewHTTPClient(schemaregistry.HTTPConfig{
+				URL:      str("url"),
+				Username: str("username"),
+				Password: str("password"),
+				Timeout:  timeout,
+			})
+			if err != nil {
+				return nil, err
+			}
+			client = schemaregistry.NewCached(hc)
+		default:
+			return nil, fmt.Errorf("schema_registry codec requires params.url or params.file")
+		}
+		autoRegister, _ := spec.Params["auto_register"].(bool)
+		return NewSchemaRegistryCodec(client, SchemaRegistryOptions{
+			Format:       str("format"),
+			Subject:      str("subject"),
+			SchemaFile:   str("schema_file"),
+			Message:      str("message"),
+			AutoRegister: autoRegister,
+			Timeout:      timeout,
+		}), nil
+	})
+}
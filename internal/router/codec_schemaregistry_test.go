@@ -0,0 +1,160 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cuongceg/validate_yaml/internal/schemaregistry"
+)
+
+const (
+	orderAvro  = `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"},{"name":"qty","type":"long"}]}`
+	orderProto = `syntax = "proto3";
+package shop.v1;
+message Header { string source = 1; }
+message Order { string id = 1; int64 qty = 2; }`
+)
+
+// fakeRegistry: schemaregistry.Memory phục vụ qua HTTP, client đi qua cache như cấu hình url.
+func fakeRegistry(t *testing.T) (schemaregistry.Client, *schemaregistry.Memory) {
+	t.Helper()
+	mem := schemaregistry.NewMemory()
+	srv := httptest.NewServer(mem)
+	t.Cleanup(srv.Close)
+	hc, err := schemaregistry.NewHTTPClient(schemaregistry.HTTPConfig{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schemaregistry.NewCached(hc), mem
+}
+
+func register(t *testing.T, mem *schemaregistry.Memory, subject string, s schemaregistry.Schema) int {
+	t.Helper()
+	id, err := mem.Register(context.Background(), subject, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestSchemaRegistryCodecAvroWireFormat(t *testing.T) {
+	client, mem := fakeRegistry(t)
+	id := register(t, mem, "orders-value", schemaregistry.Schema{Schema: orderAvro})
+	c := NewSchemaRegistryCodec(client, SchemaRegistryOptions{Format: "avro", Subject: "orders-value"})
+
+	b, err := c.Encode(map[string]any{"id": "o-1", "qty": 3}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotID, _, err := schemaregistry.ParseHeader(b)
+	if err != nil || gotID != id {
+		t.Fatalf("wire header id = %d, %v; want %d", gotID, err, id)
+	}
+
+	obj, dom, err := c.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj["id"] != "o-1" || obj["qty"] != int64(3) {
+		t.Fatalf("Decode = %#v", obj)
+	}
+	// không có schema đầu ra: Encode dùng lại schema ID của message nguồn
+	passthrough := NewSchemaRegistryCodec(client, SchemaRegistryOptions{})
+	again, err := passthrough.Encode(obj, dom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, b) {
+		t.Fatalf("re-encode = %v, want %v", again, b)
+	}
+	if got := c.ContentType(); got != "application/vnd.confluent.avro" {
+		t.Errorf("ContentType = %q", got)
+	}
+}
+
+func TestSchemaRegistryCodecProtobufMessageIndexes(t *testing.T) {
+	client, mem := fakeRegistry(t)
+	id := register(t, mem, "orders-value", schemaregistry.Schema{Type: schemaregistry.TypeProtobuf, Schema: orderProto})
+	c := NewSchemaRegistryCodec(client, SchemaRegistryOptions{Format: "protobuf", Subject: "orders-value", Message: "shop.v1.Order"})
+
+	b, err := c.Encode(map[string]any{"id": "o-2", "qty": 5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotID, rest, err := schemaregistry.ParseHeader(b)
+	if err != nil || gotID != id {
+		t.Fatalf("wire header id = %d, %v; want %d", gotID, err, id)
+	}
+	idx, _, err := schemaregistry.ReadMessageIndexes(rest)
+	if err != nil || !reflect.DeepEqual(idx, []int{1}) {
+		t.Fatalf("message indexes = %v, %v; want [1] (Order is the second message)", idx, err)
+	}
+
+	obj, _, err := c.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj["id"] != "o-2" || obj["qty"] != int64(5) {
+		t.Fatalf("Decode = %#v", obj)
+	}
+}
+
+func TestSchemaRegistryCodecJSON(t *testing.T) {
+	client, mem := fakeRegistry(t)
+	id := register(t, mem, "events-value", schemaregistry.Schema{Type: schemaregistry.TypeJSON, Schema: `{"type":"object"}`})
+	c := NewSchemaRegistryCodec(client, SchemaRegistryOptions{Format: "json", Subject: "events-value"})
+
+	b, err := c.Encode(map[string]any{"kind": "click"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append(schemaregistry.AppendHeader(nil, id), `{"kind":"click"}`...); !bytes.Equal(b, want) {
+		t.Fatalf("Encode = %q, want %q", b, want)
+	}
+	obj, _, err := c.Decode(b)
+	if err != nil || obj["kind"] != "click" {
+		t.Fatalf("Decode = %#v, %v", obj, err)
+	}
+}
+
+func TestSchemaRegistryCodecUnknownID(t *testing.T) {
+	client, _ := fakeRegistry(t)
+	c := NewSchemaRegistryCodec(client, SchemaRegistryOptions{})
+
+	_, _, err := c.Decode(append(schemaregistry.AppendHeader(nil, 404), "x"...))
+	if !errors.Is(err, schemaregistry.ErrNotFound) {
+		t.Fatalf("Decode(unknown id) = %v, want ErrNotFound", err)
+	}
+	if _, _, err := c.Decode([]byte(`{"not":"wire format"}`)); err == nil {
+		t.Fatal("Decode without magic byte should fail")
+	}
+}
+
+func TestSchemaRegistryCodecAutoRegister(t *testing.T) {
+	client, mem := fakeRegistry(t)
+	file := filepath.Join(t.TempDir(), "order.avsc")
+	if err := os.WriteFile(file, []byte(orderAvro), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lookup := NewSchemaRegistryCodec(client, SchemaRegistryOptions{Format: "avro", Subject: "orders-value", SchemaFile: file})
+	if _, err := lookup.Encode(map[string]any{"id": "o-3", "qty": 1}, nil); !errors.Is(err, schemaregistry.ErrNotFound) {
+		t.Fatalf("Encode with unregistered schema_file = %v, want ErrNotFound", err)
+	}
+
+	c := NewSchemaRegistryCodec(client, SchemaRegistryOptions{Format: "avro", Subject: "orders-value", SchemaFile: file, AutoRegister: true})
+	b, err := c.Encode(map[string]any{"id": "o-3", "qty": 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _, _ := schemaregistry.ParseHeader(b)
+	if s, err := mem.Version(context.Background(), "orders-value", 0); err != nil || s.ID != id {
+		t.Fatalf("registered = %+v, %v; want id %d", s, err, id)
+	}
+}
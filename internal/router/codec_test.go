@@ -0,0 +1,186 @@
+package router
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+)
+
+const orderRecordAvro = `{
+  "type": "record", "name": "Order",
+  "fields": [
+    {"name": "id", "type": "long"},
+    {"name": "qty", "type": "int"},
+    {"name": "price", "type": "float"},
+    {"name": "total", "type": "double"},
+    {"name": "note", "type": ["null", "string"], "default": null},
+    {"name": "tags", "type": {"type": "array", "items": "string"}},
+    {"name": "customer", "type": {
+      "type": "record", "name": "Customer",
+      "fields": [{"name": "name", "type": "string"}, {"name": "age", "type": "int"}]
+    }}
+  ]
+}`
+
+func newAvro(t *testing.T) *AvroCodec {
+	t.Helper()
+	c, err := NewAvroCodec(avro.MustParse(orderRecordAvro))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// Decode -> Encode -> Decode cho ra cùng giá trị, với mỗi codec có cấu trúc.
+func TestCodecRoundTrip(t *testing.T) {
+	avroCodec := newAvro(t)
+	avroIn, err := avro.Marshal(avroCodec.schema, map[string]any{
+		"id": int64(1) << 40, "qty": 3, "price": float32(1.5), "total": 4.5,
+		"note": "gift", "tags": []any{"a", "b"},
+		"customer": map[string]any{"name": "An", "age": 30},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgpackIn, err := NewMsgpackCodec().Encode(map[string]any{
+		"id": int64(9007199254740993), "name": "An", "price": 1.25,
+		"items": []any{map[string]any{"sku": "x"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		codec       PayloadCodec
+		in          []byte
+		contentType string
+	}{
+		{"json", NewJSONCodec(), []byte(`{"id":9007199254740993,"price":1.25,"name":"An","items":[{"sku":"x"}],"ok":true,"none":null}`), "application/json"},
+		{"avro", avroCodec, avroIn, "avro/binary"},
+		{"msgpack", NewMsgpackCodec(), msgpackIn, "application/msgpack"},
+	}
+	for _, tt := range tests {
+		if got := tt.codec.ContentType(); got != tt.contentType {
+			t.Errorf("%s: ContentType = %q, want %q", tt.name, got, tt.contentType)
+		}
+		obj, orig, err := tt.codec.Decode(tt.in)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", tt.name, err)
+		}
+		out, err := tt.codec.Encode(obj, orig)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", tt.name, err)
+		}
+		again, _, err := tt.codec.Decode(out)
+		if err != nil {
+			t.Fatalf("%s: decode after encode: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(obj, again) {
+			t.Errorf("%s: round trip\n got %#v\nwant %#v", tt.name, again, obj)
+		}
+	}
+}
+
+func TestJSONCodecKeepsInt64(t *testing.T) {
+	obj, _, err := NewJSONCodec().Decode([]byte(`{"id":9007199254740993,"n":{"v":[1,2.5]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := obj["id"].(int64); !ok || id != 9007199254740993 {
+		t.Fatalf("id = %#v, want int64 9007199254740993", obj["id"])
+	}
+	v := obj["n"].(map[string]any)["v"].([]any)
+	if _, ok := v[0].(int64); !ok {
+		t.Errorf("v[0] = %T, want int64", v[0])
+	}
+	if _, ok := v[1].(float64); !ok {
+		t.Errorf("v[1] = %T, want float64", v[1])
+	}
+	out, _ := NewJSONCodec().Encode(obj, nil)
+	if !bytes.Contains(out, []byte(`9007199254740993`)) {
+		t.Errorf("encoded %s lost int64 precision", out)
+	}
+
+	for _, bad := range []string{`[1,2]`, `"s"`, `{`} {
+		if _, _, err := NewJSONCodec().Decode([]byte(bad)); err == nil {
+			t.Errorf("Decode(%s) should fail", bad)
+		}
+	}
+}
+
+func TestMsgpackCodecNonStringKeys(t *testing.T) {
+	b, err := NewMsgpackCodec().Encode(map[string]any{"m": map[int]string{1: "one"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, _, err := NewMsgpackCodec().Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := obj["m"]; !reflect.DeepEqual(got, map[string]any{"1": "one"}) {
+		t.Fatalf("m = %#v", got)
+	}
+}
+
+// obj từ JSON/CEL (int64/float64) được ép theo schema Avro trước khi marshal.
+func TestCoerceAvro(t *testing.T) {
+	c := newAvro(t)
+	obj := map[string]any{
+		"id":       float64(7), // JSON có thể ra float64 nguyên
+		"qty":      int64(3),
+		"price":    int64(2),
+		"total":    int64(10),
+		"note":     nil,
+		"tags":     []any{"x"},
+		"customer": map[string]any{"name": "An", "age": int64(30), "extra": true},
+	}
+	got, err := coerceAvro(c.schema, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"id": int64(7), "qty": 3, "price": float32(2), "total": float64(10),
+		"note": nil, "tags": []any{"x"},
+		"customer": map[string]any{"name": "An", "age": 30},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("coerceAvro\n got %#v\nwant %#v", got, want)
+	}
+	if _, err := c.Encode(obj, nil); err != nil {
+		t.Fatalf("Encode after coerce: %v", err)
+	}
+
+	_, err = coerceAvro(c.schema, map[string]any{"customer": "not a record"})
+	if err == nil || !strings.Contains(err.Error(), "customer.record") {
+		t.Fatalf("nested record error = %v", err)
+	}
+	if _, err := NewAvroCodec(avro.MustParse(`"string"`)); err == nil {
+		t.Fatal("non-record schema should be rejected")
+	}
+}
+
+func TestRawCodecPassthrough(t *testing.T) {
+	c := NewRawCodec()
+	if !passthrough(c) || passthrough(NewJSONCodec()) {
+		t.Fatal("passthrough mismatch")
+	}
+	if c.ContentType() != "application/octet-stream" {
+		t.Fatalf("ContentType = %q", c.ContentType())
+	}
+	in := []byte{0x00, 0xff, '{', 0x10}
+	obj, orig, err := c.Decode(in)
+	if err != nil || obj != nil {
+		t.Fatalf("Decode = (%v, %v)", obj, err)
+	}
+	out, err := c.Encode(nil, orig)
+	if err != nil || !bytes.Equal(out, in) {
+		t.Fatalf("Encode = (%v, %v), want original bytes", out, err)
+	}
+	if _, err := c.Encode(map[string]any{"a": 1}, nil); err == nil {
+		t.Fatal("structured payload should not encode as raw")
+	}
+}
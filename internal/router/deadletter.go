@@ -0,0 +1,102 @@
+package router
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Header keys gắn vào message gửi tới dead-letter target.
+const (
+	MetaDLQReason          = "dlq-reason"
+	MetaDLQRoute           = "dlq-route"
+	MetaDLQAttempts        = "dlq-attempts"
+	MetaDLQTarget          = "dlq-target"
+	MetaDLQSourceConnector = "dlq-source-connector"
+	MetaDLQSource          = "dlq-source"
+	MetaDLQFailedAtMs      = "dlq-failed-at-ms"
+)
+
+// Header keys gắn vào message gửi tới reject target (không qua schema của route).
+const (
+	MetaRejectReason          = "reject-reason"
+	MetaRejectRoute           = "reject-route"
+	MetaRejectSourceConnector = "reject-source-connector"
+	MetaRejectSource          = "reject-source"
+	MetaRejectAtMs            = "reject-at-ms"
+)
+
+// letterKeys: bộ header của một loại đích (dead-letter/reject); "" -> không gắn.
+type letterKeys struct {
+	reason, route, attempts, target, sourceConnector, source, at string
+}
+
+var (
+	dlqKeys = letterKeys{
+		reason: MetaDLQReason, route: MetaDLQRoute, attempts: MetaDLQAttempts, target: MetaDLQTarget,
+		sourceConnector: MetaDLQSourceConnector, source: MetaDLQSource, at: MetaDLQFailedAtMs,
+	}
+	rejectKeys = letterKeys{
+		reason: MetaRejectReason, route: MetaRejectRoute,
+		sourceConnector: MetaRejectSourceConnector, source: MetaRejectSource, at: MetaRejectAtMs,
+	}
+)
+
+// deadLetter publish bytes gốc + lý do lỗi tới egress được cấu hình trong
+// route.dead_letter (header dlq-*) hoặc route.reject (header reject-*).
+type deadLetter struct {
+	bus       Bus
+	connector string
+	target    string
+	route     string
+	fromConn  string
+	fromSrc   string
+	keys      letterKeys
+	policy    RetryPolicy
+}
+
+func newDeadLetter(bus Bus, keys letterKeys, connector, target, route, fromConn, fromSrc string) *deadLetter {
+	return &deadLetter{
+		bus:       bus,
+		connector: connector,
+		target:    target,
+		route:     route,
+		fromConn:  fromConn,
+		fromSrc:   fromSrc,
+		keys:      keys,
+		policy: RetryPolicy{
+			MaxAttempts: 3,
+			BaseBackoff: 50 * time.Millisecond,
+			MaxBackoff:  time.Second,
+		},
+	}
+}
+
+// send trả nil nếu message đã nằm trong DLQ (ingress có thể ack).
+func (d *deadLetter) send(ctx context.Context, orig []byte, srcMeta map[string]any, failedTarget, reason string, attempts int) error {
+	meta := make(map[string]any, len(srcMeta)+7)
+	for k, v := range srcMeta {
+		meta[k] = v
+	}
+	k := d.keys
+	meta[k.reason] = reason
+	meta[k.route] = d.route
+	meta[k.sourceConnector] = d.fromConn
+	meta[k.source] = d.fromSrc
+	meta[k.at] = strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if k.attempts != "" {
+		meta[k.attempts] = strconv.Itoa(attempts)
+	}
+	if k.target != "" && failedTarget != "" {
+		meta[k.target] = failedTarget
+	}
+
+	msg := &Message{Value: orig, Meta: meta}
+	outcome, _, err := d.policy.Run(ctx, time.Now(), func(ctx context.Context) error {
+		return d.bus.Publish(ctx, d.target, msg)
+	})
+	if outcome != OutcomeDelivered {
+		return err
+	}
+	return nil
+}
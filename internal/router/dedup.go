@@ -0,0 +1,121 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/dedup"
+	"github.com/cuongceg/validate_yaml/internal/expr"
+)
+
+// deduper bỏ qua message đã giao tới target: key = route/connector/target/id.
+// Claim (TTL ngắn = thời gian giao tối đa) trước khi publish, gia hạn claim mỗi
+// lần retry, Mark (TTL dedup) sau khi target xác nhận delivered, Release nếu giao
+// không thành công.
+type deduper struct {
+	store    dedup.Store
+	route    string
+	ttl      time.Duration
+	claimTTL time.Duration // giữ key khi đang giao; process chết giữa chừng thì key tự hết hạn
+	id       func(meta, obj map[string]any, value []byte) (string, bool)
+}
+
+// defaultClaimTTL: thời gian giữ key khi route không giới hạn thời gian giao (mode.ttl).
+// Route persistent retry mãi: claim được gia hạn mỗi lần thử (refresh), nên chỉ cần
+// lớn hơn một lần publish + backoff tối đa.
+const defaultClaimTTL = 5 * time.Minute
+
+func newDeduper(store dedup.Store, route string, d *cfg.RouteDedup, claimTTL time.Duration) (*deduper, error) {
+	if store == nil {
+		return nil, fmt.Errorf("dedup needs a store (Engine.Dedup or redis)")
+	}
+	id, err := messageID(d.IDFrom)
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(d.TTLms) * time.Millisecond
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	if claimTTL <= 0 {
+		claimTTL = defaultClaimTTL
+	}
+	return &deduper{store: store, route: route, ttl: ttl, claimTTL: min(claimTTL, ttl), id: id}, nil
+}
+
+// messageID: "" | "idempotencyKey" -> meta idempotencyKey; "header.X" / "meta.X" |
+// "payload.a.b" | "content_hash" (hash bytes, phải khai báo rõ: hai message hợp lệ
+// trùng nội dung sẽ bị coi là bản lặp). Message không có ID thì không dedup.
+func messageID(spec string) (func(meta, obj map[string]any, value []byte) (string, bool), error) {
+	fromMeta := func(key string) func(meta, obj map[string]any, value []byte) (string, bool) {
+		return func(meta, _ map[string]any, _ []byte) (string, bool) {
+			if v, ok := meta[key]; ok && v != nil && fmt.Sprint(v) != "" {
+				return fmt.Sprint(v), true
+			}
+			return "", false
+		}
+	}
+	switch {
+	case spec == "", spec == "idempotencyKey":
+		return fromMeta("idempotencyKey"), nil
+	case spec == "content_hash":
+		return func(_, _ map[string]any, value []byte) (string, bool) {
+			return contentHash(value), true
+		}, nil
+	case strings.HasPrefix(spec, "header."):
+		// header được ingress chép vào meta (headers_to_meta)
+		return fromMeta(strings.TrimPrefix(spec, "header.")), nil
+	}
+	root, segs, err := expr.SplitPath(spec)
+	if err != nil {
+		return nil, err
+	}
+	if root == "meta" {
+		return fromMeta(segs[0]), nil
+	}
+	return func(_, obj map[string]any, _ []byte) (string, bool) {
+		v, ok := expr.Lookup(obj, segs)
+		if !ok || v == nil {
+			return "", false
+		}
+		return toString(v), true
+	}, nil
+}
+
+func contentHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (d *deduper) key(target, id string) string {
+	return d.route + "/" + target + "/" + id
+}
+
+// claim: false -> target đã nhận (hoặc đang nhận) message này.
+// Lỗi store -> coi như chưa giao (at-least-once, không mất message).
+func (d *deduper) claim(ctx context.Context, target, id string) (bool, error) {
+	return d.store.Claim(ctx, d.key(target, id), d.claimTTL)
+}
+
+// refresh: gia hạn claim thêm claimTTL khi đang retry.
+func (d *deduper) refresh(ctx context.Context, target, id string) error {
+	return d.store.Mark(ctx, d.key(target, id), d.claimTTL)
+}
+
+func (d *deduper) mark(ctx context.Context, target, id string) error {
+	return d.store.Mark(ctx, d.key(target, id), d.ttl)
+}
+
+func (d *deduper) release(ctx context.Context, target, id string) error {
+	return d.store.Release(ctx, d.key(target, id))
+}
+
+// usesPayloadID: message ID đọc từ payload (cần codec decode được).
+func usesPayloadID(r cfg.Route) bool {
+	return r.Dedup != nil && strings.HasPrefix(r.Dedup.IDFrom, "payload.")
+}
@@ -0,0 +1,189 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/dedup"
+)
+
+func TestMessageIDModes(t *testing.T) {
+	meta := map[string]any{"idempotencyKey": "idem-1", "X-Order": "ord-7", "tenant": "acme"}
+	obj := map[string]any{"order": map[string]any{"id": 42}}
+	value := []byte(`{"order":{"id":42}}`)
+
+	tests := []struct {
+		spec   string
+		want   string
+		wantOK bool
+	}{
+		{"", "idem-1", true},
+		{"idempotencyKey", "idem-1", true},
+		{"header.X-Order", "ord-7", true},
+		{"meta.tenant", "acme", true},
+		{"payload.order.id", "42", true},
+		{"content_hash", contentHash(value), true},
+		{"meta.missing", "", false},
+		{"payload.order.missing", "", false},
+	}
+	for _, tt := range tests {
+		id, err := messageID(tt.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.spec, err)
+		}
+		got, ok := id(meta, obj, value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%q: got (%q, %v), want (%q, %v)", tt.spec, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if _, err := messageID("hash"); err == nil {
+		t.Error(`"hash" should be rejected, the opt-in is "content_hash"`)
+	}
+}
+
+// không cấu hình id_from và message không có idempotencyKey: không dedup theo nội dung.
+func TestMessageIDDefaultDoesNotHashContent(t *testing.T) {
+	id, err := messageID("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := id(map[string]any{}, nil, []byte("same bytes")); ok {
+		t.Fatalf("default id_from produced %q for a message without idempotencyKey", got)
+	}
+}
+
+func dedupRoute(idFrom string, ttlMs int64) cfg.Route {
+	return cfg.Route{Name: "r", Mode: cfg.RouteMode{Type: "drop"}, Dedup: &cfg.RouteDedup{IDFrom: idFrom, TTLms: ttlMs}}
+}
+
+func TestDedupSkipsDuplicates(t *testing.T) {
+	e := &Engine{Dedup: dedup.NewMemoryStore()}
+	bus := startRoutes(t, e, dedupRoute("payload.id", 0))
+
+	for _, v := range []string{`{"id":1}`, `{"id":1}`, `{"id":2}`} {
+		if err := bus.deliver(t, "in", v, nil); err != nil {
+			t.Fatalf("deliver %s: %v", v, err)
+		}
+	}
+	if n := len(bus.sent("out")); n != 2 {
+		t.Fatalf("published %d messages, want 2", n)
+	}
+	st := e.Stats()["r"]
+	if st.Duplicates != 1 || st.DedupHits != 1 || st.DedupMisses != 2 {
+		t.Fatalf("stats = %+v", st)
+	}
+}
+
+func TestDedupWithoutIDDeliversEverything(t *testing.T) {
+	e := &Engine{Dedup: dedup.NewMemoryStore()}
+	bus := startRoutes(t, e, dedupRoute("", 0))
+
+	for i := 0; i < 2; i++ {
+		if err := bus.deliver(t, "in", `{"same":"bytes"}`, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(bus.sent("out")); n != 2 {
+		t.Fatalf("published %d messages, want 2 (no idempotencyKey -> no dedup)", n)
+	}
+	if err := bus.deliver(t, "in", `{}`, map[string]any{"idempotencyKey": "k"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.deliver(t, "in", `{}`, map[string]any{"idempotencyKey": "k"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(bus.sent("out")); n != 3 {
+		t.Fatalf("published %d messages, want 3", n)
+	}
+}
+
+func TestDedupTTLExpiry(t *testing.T) {
+	e := &Engine{Dedup: dedup.NewMemoryStore()}
+	bus := startRoutes(t, e, dedupRoute("content_hash", 50))
+
+	for i := 0; i < 2; i++ {
+		if err := bus.deliver(t, "in", `{"id":1}`, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(bus.sent("out")); n != 1 {
+		t.Fatalf("published %d messages before TTL, want 1", n)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if err := bus.deliver(t, "in", `{"id":1}`, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(bus.sent("out")); n != 2 {
+		t.Fatalf("published %d messages after TTL, want 2", n)
+	}
+}
+
+// bản lặp đến đồng thời: chỉ một bản được giao (Claim là set-if-absent).
+func TestDedupConcurrentDuplicates(t *testing.T) {
+	e := &Engine{Dedup: dedup.NewMemoryStore()}
+	bus := startRoutes(t, e, dedupRoute("payload.id", 0))
+	bus.deliver(t, "in", `{"id":0}`, nil) // chờ subscribe
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := bus.deliver(t, "in", `{"id":1}`, nil); err != nil && !errors.Is(err, errDuplicate) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(bus.sent("out")); n != 2 {
+		t.Fatalf("published %d messages, want 2", n)
+	}
+}
+
+// ttlStore ghi lại TTL của mỗi lần Mark.
+type ttlStore struct {
+	*dedup.MemoryStore
+	mu    sync.Mutex
+	marks []time.Duration
+}
+
+func (s *ttlStore) Mark(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	s.marks = append(s.marks, ttl)
+	s.mu.Unlock()
+	return s.MemoryStore.Mark(ctx, key, ttl)
+}
+
+// route persistent retry không giới hạn: claim được gia hạn mỗi lần retry
+// để không hết hạn giữa chừng, sau khi giao thì Mark với TTL dedup.
+func TestDedupRefreshesClaimOnRetry(t *testing.T) {
+	store := &ttlStore{MemoryStore: dedup.NewMemoryStore()}
+	e := &Engine{Dedup: store}
+	r := dedupRoute("payload.id", 0)
+	r.Mode = cfg.RouteMode{Type: "persistent"}
+	bus := startRoutes(t, e, r)
+	bus.fail("out", 2)
+
+	if err := bus.deliver(t, "in", `{"id":1}`, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Duration{defaultClaimTTL, defaultClaimTTL, 24 * time.Hour}
+	if !reflect.DeepEqual(store.marks, want) {
+		t.Fatalf("marks = %v, want %v (refresh per retry, then mark)", store.marks, want)
+	}
+	if st := e.Stats()["r"]; st.Retried != 2 || st.Delivered != 1 {
+		t.Fatalf("stats = %+v", st)
+	}
+	if err := bus.deliver(t, "in", `{"id":1}`, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(bus.sent("out")); n != 1 {
+		t.Fatalf("published %d messages, want 1", n)
+	}
+}
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"errors"
+	"testing"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/core"
+)
+
+// hai route chung nguồn "in", mỗi route một đích và một filter riêng.
+func fanOutEngine(t *testing.T, filterA, filterB string) (*Engine, *fakeBus) {
+	t.Helper()
+	filters, err := CompileFilters([]cfg.FilterRule{{Name: "a", Expr: filterA}, {Name: "b", Expr: filterB}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transforms, err := CompileTransforms([]cfg.TransformRule{
+		{Name: "tag-a", Ops: []cfg.TransformOp{{Op: "set", Field: "meta.tag", Value: "a"}}},
+		{Name: "tag-b", Ops: []cfg.TransformOp{{Op: "set", Field: "meta.tag", Value: "b"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Engine{Filters: filters, Transforms: transforms}
+	bus := startRoutes(t, e,
+		cfg.Route{Name: "ra", Mode: cfg.RouteMode{Type: "drop"}, To: to("out-a"), Filters: []string{"a"}, Transform: "tag-a"},
+		cfg.Route{Name: "rb", Mode: cfg.RouteMode{Type: "drop"}, To: to("out-b"), Filters: []string{"b"}, Transform: "tag-b"},
+	)
+	return e, bus
+}
+
+// một route lỗi, route kia giao được: không ack để ingress giao lại.
+func TestFanOutOneRouteFails(t *testing.T) {
+	e, bus := fanOutEngine(t, `payload.n / 0 == 1`, `true`)
+	err := bus.deliver(t, "in", `{"n":1}`, nil)
+	if core.Acked(err) {
+		t.Fatalf("err = %v, want an error that is not acked", err)
+	}
+	if n := len(bus.sent("out-b")); n != 1 {
+		t.Fatalf("out-b received %d, want 1", n)
+	}
+	st := e.Stats()
+	if st["ra"].Failed != 1 || st["rb"].Delivered != 1 {
+		t.Fatalf("stats = %+v", st)
+	}
+}
+
+func TestFanOutAllFiltered(t *testing.T) {
+	_, bus := fanOutEngine(t, `false`, `payload.n > 5`)
+	err := bus.deliver(t, "in", `{"n":1}`, nil)
+	if !errors.Is(err, core.ErrFiltered) {
+		t.Fatalf("err = %v, want ErrFiltered", err)
+	}
+}
+
+// một route lọc, route kia giao: ack (nil), không phải ErrFiltered.
+func TestFanOutFilteredAndDelivered(t *testing.T) {
+	_, bus := fanOutEngine(t, `false`, `true`)
+	if err := bus.deliver(t, "in", `{"n":1}`, nil); err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+}
+
+// mỗi route có bản meta riêng: meta route này ghi không lộ sang route kia hay message gốc.
+func TestFanOutClonesMeta(t *testing.T) {
+	_, bus := fanOutEngine(t, `true`, `true`)
+	meta := map[string]any{"tenant": "acme"}
+	if err := bus.deliver(t, "in", `{"n":1}`, meta); err != nil {
+		t.Fatal(err)
+	}
+	a, b := bus.sent("out-a"), bus.sent("out-b")
+	if len(a) != 1 || len(b) != 1 {
+		t.Fatalf("published %d/%d, want 1/1", len(a), len(b))
+	}
+	if a[0].Meta["tag"] != "a" || a[0].Meta["route"] != "ra" {
+		t.Errorf("out-a meta = %v", a[0].Meta)
+	}
+	if b[0].Meta["tag"] != "b" || b[0].Meta["route"] != "rb" {
+		t.Errorf("out-b meta = %v", b[0].Meta)
+	}
+	if a[0].Meta["tenant"] != "acme" || b[0].Meta["tenant"] != "acme" {
+		t.Error("source meta not copied to every route")
+	}
+	if _, ok := meta["tag"]; ok || meta["route"] != nil {
+		t.Errorf("ingress meta modified by a route: %v", meta)
+	}
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Header keys engine gắn vào mọi message publish, để phát hiện vòng lặp giữa các
+// bridge (vd Kafka -> RabbitMQ -> Kafka): hops là danh sách connector/source đã đi qua.
+const (
+	MetaMsgID = "msg_id"
+	MetaHops  = "hops"
+
+	defaultMaxHops = 10
+	hopSep         = ","
+)
+
+// hopsOf đọc danh sách hop từ meta (header "hops" của message nhận được).
+func hopsOf(meta map[string]any) []string {
+	s, _ := meta[MetaHops].(string)
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, hopSep)
+}
+
+// checkHop: message đã qua hop này (vòng lặp) hoặc đã vượt maxHops -> lỗi.
+func checkHop(hops []string, hop string, maxHops int) error {
+	for _, h := range hops {
+		if h == hop {
+			return fmt.Errorf("loop detected: %s already in hops [%s]", hop, strings.Join(hops, hopSep))
+		}
+	}
+	if len(hops) >= maxHops {
+		return fmt.Errorf("max hops %d exceeded: [%s]", maxHops, strings.Join(hops, hopSep))
+	}
+	return nil
+}
+
+// stampHop gắn msg_id (giữ nguyên nếu message đã có) và nối hop hiện tại vào hops.
+func stampHop(meta map[string]any, hops []string, hop string) {
+	if id, _ := meta[MetaMsgID].(string); id == "" {
+		meta[MetaMsgID] = newMsgID()
+	}
+	meta[MetaHops] = strings.Join(append(hops, hop), hopSep)
+}
+
+func newMsgID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"strings"
+	"testing"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+)
+
+func TestCheckHop(t *testing.T) {
+	if err := checkHop(nil, "k/in", 10); err != nil {
+		t.Fatalf("first hop: %v", err)
+	}
+	if err := checkHop([]string{"n/orders", "k/in"}, "k/in", 10); err == nil || !strings.Contains(err.Error(), "loop detected") {
+		t.Fatalf("revisited hop: %v", err)
+	}
+	if err := checkHop([]string{"a/1", "b/2"}, "k/in", 2); err == nil || !strings.Contains(err.Error(), "max hops") {
+		t.Fatalf("max hops: %v", err)
+	}
+}
+
+func TestStampHop(t *testing.T) {
+	meta := map[string]any{}
+	stampHop(meta, nil, "k/in")
+	id, _ := meta[MetaMsgID].(string)
+	if len(id) != 32 || meta[MetaHops] != "k/in" {
+		t.Fatalf("meta = %v", meta)
+	}
+	// message đã có msg_id: giữ nguyên, nối thêm hop
+	stampHop(meta, hopsOf(meta), "n/orders")
+	if meta[MetaMsgID] != id || meta[MetaHops] != "k/in,n/orders" {
+		t.Fatalf("meta = %v", meta)
+	}
+}
+
+func TestRouteDropsLoopsAndStampsHops(t *testing.T) {
+	e := &Engine{}
+	bus := startRoutes(t, e, cfg.Route{Name: "r", Mode: cfg.RouteMode{Type: "drop"}, MaxHops: 2})
+
+	if err := bus.deliver(t, "in", `{}`, map[string]any{MetaMsgID: "m-1", MetaHops: "other/x"}); err != nil {
+		t.Fatal(err)
+	}
+	sent := bus.sent("out")
+	if len(sent) != 1 {
+		t.Fatalf("published %d, want 1", len(sent))
+	}
+	if got := sent[0].Meta; got[MetaMsgID] != "m-1" || got[MetaHops] != "other/x,bus/in" {
+		t.Fatalf("stamped meta = %v", got)
+	}
+
+	// message quay lại nguồn đã đi qua -> bỏ (ack), không publish
+	if err := bus.deliver(t, "in", `{}`, map[string]any{MetaHops: "bus/in,other/x"}); err != nil {
+		t.Fatalf("looped message should be acked, got %v", err)
+	}
+	// đã qua 2 hop (max_hops: 2) -> bỏ
+	if err := bus.deliver(t, "in", `{}`, map[string]any{MetaHops: "a/1,b/2"}); err != nil {
+		t.Fatalf("message over max_hops should be acked, got %v", err)
+	}
+	if n := len(bus.sent("out")); n != 1 {
+		t.Fatalf("published %d, want 1", n)
+	}
+	if st := e.Stats()["r"]; st.Looped != 2 || st.Delivered != 1 {
+		t.Fatalf("stats = %+v", st)
+	}
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync/atomic"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/expr"
+)
+
+// laneSelector chọn lane publish cho message theo ordering của route:
+//   - none: round-robin, không đảm bảo thứ tự
+//   - key: cùng partition key -> cùng lane -> FIFO theo key tới mỗi target;
+//     message không có key thì round-robin
+//   - strict: một lane duy nhất mỗi target -> FIFO toàn route
+//
+// Thứ tự chỉ giữ được theo thứ tự ingress gọi handler, tức là thứ tự của nguồn:
+// Kafka tuần tự theo partition (các partition chạy song song), RabbitMQ tuần tự theo
+// queue, NATS tuần tự theo subscription. Với Kafka, key: chỉ là FIFO theo key khi
+// message cùng key nằm cùng partition (vd partition_key là Kafka key); strict là FIFO
+// theo partition, không có thứ tự giữa các partition.
+type laneSelector struct {
+	lanes int
+	rr    atomic.Uint64
+	key   func(meta, obj map[string]any) (string, bool) // nil: không theo key
+}
+
+const (
+	orderingNone   = "none"
+	orderingKey    = "key"
+	orderingStrict = "strict"
+)
+
+func orderingOf(r cfg.Route) string {
+	if o := strings.ToLower(r.Ordering); o != "" {
+		return o
+	}
+	return orderingNone
+}
+
+func newLaneSelector(r cfg.Route, lanes int) (*laneSelector, error) {
+	s := &laneSelector{lanes: lanes}
+	switch orderingOf(r) {
+	case orderingNone:
+	case orderingStrict:
+		s.lanes = 1
+	case orderingKey:
+		fn, err := partitionKey(r.PartitionKey)
+		if err != nil {
+			return nil, err
+		}
+		s.key = fn
+	default:
+		return nil, fmt.Errorf("unknown ordering %q", r.Ordering)
+	}
+	return s, nil
+}
+
+// partitionKey: "" -> route_key rồi tới key (Kafka message key); "meta.X" / "payload.a.b".
+func partitionKey(spec string) (func(meta, obj map[string]any) (string, bool), error) {
+	metaKey := func(keys ...string) func(meta, obj map[string]any) (string, bool) {
+		return func(meta, _ map[string]any) (string, bool) {
+			for _, k := range keys {
+				if v, ok := meta[k]; ok && v != nil && fmt.Sprint(v) != "" {
+					return fmt.Sprint(v), true
+				}
+			}
+			return "", false
+		}
+	}
+	switch spec {
+	case "":
+		return metaKey("route_key", "key"), nil
+	case "route_key", "key":
+		return metaKey(spec), nil
+	}
+	root, segs, err := expr.SplitPath(spec)
+	if err != nil {
+		return nil, err
+	}
+	if root == "meta" {
+		return metaKey(segs[0]), nil
+	}
+	return func(_, obj map[string]any) (string, bool) {
+		v, ok := expr.Lookup(obj, segs)
+		if !ok || v == nil {
+			return "", false
+		}
+		return toString(v), true
+	}, nil
+}
+
+func (s *laneSelector) pick(meta, obj map[string]any) int {
+	if s.lanes <= 1 {
+		return 0
+	}
+	if s.key != nil {
+		if k, ok := s.key(meta, obj); ok {
+			h := fnv.New32a()
+			h.Write([]byte(k))
+			return int(h.Sum32() % uint32(s.lanes))
+		}
+	}
+	return int(s.rr.Add(1) % uint64(s.lanes))
+}
+
+// usesPayload: partition key đọc từ payload (cần codec decode được).
+func usesPayload(r cfg.Route) bool {
+	return orderingOf(r) == orderingKey && strings.HasPrefix(r.PartitionKey, "payload.")
+}
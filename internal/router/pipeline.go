@@ -2,87 +2,120 @@ package router
 
 import (
 	"context"
-	"strconv"
+	"fmt"
 	"strings"
-	"time"
-)
 
-// Đăng ký các filter có tên trùng với YAML.
-// Bạn có thể thay thế bằng CEL sau này (cel-go).
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/expr"
+)
 
-func BuiltinFilters() map[string]FilterFn {
-	return map[string]FilterFn{
-		// ví dụ từ YAML:
-		// expr: 'meta.size <= 1048576'
-		"size_le_1mb": func(ctx context.Context, msg *Message, obj map[string]any) (bool, error) {
-			sz, _ := getInt64(msg.Meta, "size")
-			return sz <= 1024*1024, nil
-		},
-		// expr: 'meta.createdAtMs >= nowMs() - 3600 * 1000'
-		"recent_1h": func(ctx context.Context, msg *Message, obj map[string]any) (bool, error) {
-			created, _ := getInt64(msg.Meta, "createdAtMs")
-			if created == 0 {
-				// nếu thiếu trường, coi như fail (giống on_missing_field: drop)
-				return false, nil
+// CompileFilters biên dịch filters[].expr (CEL) thành FilterFn theo tên.
+// on_missing_field: drop (mặc định) | false -> message bị loại; skip -> bỏ qua filter.
+func CompileFilters(rules []cfg.FilterRule) (map[string]FilterFn, error) {
+	out := make(map[string]FilterFn, len(rules))
+	for _, r := range rules {
+		prg, err := expr.Compile(r.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %w", r.Name, err)
+		}
+		onMissing := strings.ToLower(r.OnMissingField)
+		out[r.Name] = func(ctx context.Context, msg *Message, obj map[string]any) (bool, error) {
+			ok, err := prg.Eval(obj, msg.Meta)
+			if err == nil {
+				return ok, nil
 			}
-			return time.Now().UnixMilli()-created <= 3600*1000, nil
-		},
-		// expr: 'has(payload.name) && (payload.age > 16 || payload.name.contains("A"))'
-		"user_basic": func(ctx context.Context, msg *Message, obj map[string]any) (bool, error) {
-			if obj == nil {
+			if expr.IsMissingField(err) {
+				if onMissing == "skip" {
+					return true, nil
+				}
 				return false, nil
 			}
+			return false, err
+		}
+	}
+	return out, nil
+}
 
-			if obj["name"] == nil || obj["age"] == nil {
-				return false, nil
-			}
-			name := ""
-			age := int64(0)
-			if v, ok := obj["name"].(string); ok {
-				name = v
-			}
-			if v, ok := strconv.ParseInt(obj["age"].(string), 10, 64); ok != nil {
-				return false, nil
-			} else {
-				age = v
-			}
-			if name == "" {
-				return false, nil
-			}
-			return age > 16 || strings.Contains(name, "A"), nil
-		},
+// payloadFilters trả tên các filter có biểu thức đọc payload.*.
+func payloadFilters(rules []cfg.FilterRule) map[string]bool {
+	out := map[string]bool{}
+	for _, r := range rules {
+		if prg, err := expr.Compile(r.Expr); err == nil && prg.UsesPayload() {
+			out[r.Name] = true
+		}
 	}
+	return out
 }
 
-func BuiltinProjections() map[string]ProjectFn {
-	return map[string]ProjectFn{
-		"keep_name_age_best_effort": func(ctx context.Context, msg *Message, obj map[string]any) (map[string]any, error) {
-			if obj == nil {
-				return map[string]any{}, nil
+// CompileProjections dựng ProjectFn từ projections[].include (payload.* / meta.*).
+//   - best_effort: true => có trường nào lấy trường đó
+//   - best_effort: false => thiếu trường thì theo on_missing_field:
+//     drop (mặc định) trả nil (route bỏ message), skip giữ nguyên payload gốc,
+//     false giữ shape đầu ra với giá trị null.
+//
+// meta.<key> được chép vào gốc payload đầu ra với tên <key>; codec protobuf bỏ
+// qua trường không có trong message (DiscardUnknown) khi encode.
+func CompileProjections(rules []cfg.ProjectionRule) (map[string]ProjectFn, error) {
+	out := make(map[string]ProjectFn, len(rules))
+	for _, r := range rules {
+		payloadTree := newPathNode()
+		var metaKeys []string
+		for _, inc := range r.Include {
+			root, segs, err := expr.SplitPath(inc)
+			if err != nil {
+				return nil, fmt.Errorf("projection %q: %w", r.Name, err)
 			}
-			out := map[string]any{}
-			if v, ok := obj["name"]; ok {
-				out["name"] = v
+			if root == "meta" {
+				metaKeys = append(metaKeys, segs[0])
+				continue
 			}
-			if v, ok := obj["age"]; ok {
-				out["age"] = v
+			payloadTree.add(segs)
+		}
+		bestEffort := r.BestEffort
+		onMissing := strings.ToLower(r.OnMissingField)
+
+		out[r.Name] = func(ctx context.Context, msg *Message, obj map[string]any) (map[string]any, error) {
+			var missing []string
+			res := map[string]any{}
+			if len(payloadTree.order) > 0 || payloadTree.leaf {
+				var src any = obj
+				if obj == nil {
+					src = map[string]any{}
+				}
+				pv, _ := payloadTree.project(src, "payload", &missing)
+				if m, ok := pv.(map[string]any); ok {
+					res = make(map[string]any, len(m))
+					for k, v := range m {
+						res[k] = v
+					}
+				}
 			}
-			return out, nil
-		},
-		"keep_name_age_strict": func(ctx context.Context, msg *Message, obj map[string]any) (map[string]any, error) {
-			if obj == nil {
-				return nil, nil // sẽ fail ở Encode -> drop
+			for _, k := range metaKeys {
+				if v, ok := msg.Meta[k]; ok {
+					res[k] = v
+				} else {
+					missing = append(missing, "meta."+k)
+				}
 			}
-			if _, ok := obj["name"]; !ok {
-				return nil, nil
+
+			if len(missing) == 0 || bestEffort {
+				return res, nil
 			}
-			if _, ok := obj["age"]; !ok {
+			switch onMissing {
+			case "skip":
+				return obj, nil
+			case "false":
+				payloadTree.fillMissing(res)
+				for _, k := range metaKeys {
+					if _, ok := res[k]; !ok {
+						res[k] = nil
+					}
+				}
+				return res, nil
+			default:
 				return nil, nil
 			}
-			return map[string]any{
-				"name": obj["name"],
-				"age":  obj["age"],
-			}, nil
-		},
+		}
 	}
+	return out, nil
 }
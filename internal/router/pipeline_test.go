@@ -0,0 +1,49 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+)
+
+func TestFilterOnMissingField(t *testing.T) {
+	tests := []struct {
+		onMissing string
+		want      bool
+	}{
+		{"", false}, // mặc định drop
+		{"drop", false},
+		{"false", false},
+		{"skip", true},
+	}
+	for _, tt := range tests {
+		fns, err := CompileFilters([]cfg.FilterRule{{Name: "f", Expr: `payload.customer.tier == "gold"`, OnMissingField: tt.onMissing}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := fns["f"]
+		msg := &Message{Meta: map[string]any{}}
+
+		got, err := f(context.Background(), msg, map[string]any{"order": map[string]any{}})
+		if err != nil || got != tt.want {
+			t.Errorf("on_missing_field=%q: missing field -> (%v, %v), want %v", tt.onMissing, got, err, tt.want)
+		}
+		// trường có mặt: kết quả của biểu thức, không phụ thuộc on_missing_field
+		got, err = f(context.Background(), msg, map[string]any{"customer": map[string]any{"tier": "silver"}})
+		if err != nil || got {
+			t.Errorf("on_missing_field=%q: present field -> (%v, %v), want false", tt.onMissing, got, err)
+		}
+	}
+}
+
+// lỗi không phải thiếu trường vẫn được trả về, kể cả với skip.
+func TestFilterEvalErrorIsNotMissing(t *testing.T) {
+	fns, err := CompileFilters([]cfg.FilterRule{{Name: "f", Expr: `payload.n / 0 == 1`, OnMissingField: "skip"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fns["f"](context.Background(), &Message{}, map[string]any{"n": int64(1)}); err == nil {
+		t.Fatal("division by zero should surface as an error")
+	}
+}
@@ -0,0 +1,94 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pathNode là cây các include path (payload.customer.name, payload.items[].sku, ...).
+// leaf=true nghĩa là lấy nguyên giá trị tại node đó.
+type pathNode struct {
+	leaf     bool
+	children map[string]*pathNode
+	order    []string
+}
+
+func newPathNode() *pathNode { return &pathNode{children: map[string]*pathNode{}} }
+
+// add thêm một path đã tách thành segments; segment "items[]" được coi như "items"
+// vì mảng luôn được duyệt ngầm định theo từng phần tử.
+func (n *pathNode) add(segs []string) {
+	if len(segs) == 0 {
+		n.leaf = true
+		return
+	}
+	key := strings.TrimSuffix(segs[0], "[]")
+	child, ok := n.children[key]
+	if !ok {
+		child = newPathNode()
+		n.children[key] = child
+		n.order = append(n.order, key)
+	}
+	child.add(segs[1:])
+}
+
+// project lấy các nhánh của v theo cây n. missing nhận các path không tồn tại.
+func (n *pathNode) project(v any, prefix string, missing *[]string) (any, bool) {
+	if n.leaf {
+		return v, true
+	}
+	switch vv := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(n.order))
+		for _, k := range n.order {
+			child := n.children[k]
+			cv, ok := vv[k]
+			if !ok {
+				*missing = append(*missing, prefix+"."+k)
+				continue
+			}
+			if pv, ok := child.project(cv, prefix+"."+k, missing); ok {
+				out[k] = pv
+			}
+		}
+		return out, true
+	case []any:
+		out := make([]any, 0, len(vv))
+		for i, item := range vv {
+			if pv, ok := n.project(item, fmt.Sprintf("%s[%d]", prefix, i), missing); ok {
+				out = append(out, pv)
+			}
+		}
+		return out, true
+	}
+	*missing = append(*missing, prefix)
+	return nil, false
+}
+
+// fillMissing đặt null cho những path thiếu (on_missing_field: false) để giữ shape đầu ra.
+func (n *pathNode) fillMissing(out map[string]any) {
+	for _, k := range n.order {
+		child := n.children[k]
+		cur, ok := out[k]
+		if !ok {
+			if child.leaf {
+				out[k] = nil
+			} else {
+				m := map[string]any{}
+				child.fillMissing(m)
+				out[k] = m
+			}
+			continue
+		}
+		switch cv := cur.(type) {
+		case map[string]any:
+			child.fillMissing(cv)
+		case []any:
+			for _, item := range cv {
+				if m, ok := item.(map[string]any); ok {
+					child.fillMissing(m)
+				}
+			}
+		}
+	}
+}
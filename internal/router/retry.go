@@ -0,0 +1,112 @@
+package router
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+)
+
+// Outcome là kết quả cuối cùng của việc gửi một message tới một target.
+type Outcome int
+
+const (
+	OutcomeDelivered Outcome = iota // publish OK
+	OutcomeExpired                  // quá ttl_ms (mode drop) -> bỏ message
+	OutcomeExhausted                // hết max_attempts (mode drop) -> bỏ message
+	OutcomeFailed                   // không gửi được (ctx bị hủy) -> không ack
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeDelivered:
+		return "delivered"
+	case OutcomeExpired:
+		return "expired"
+	case OutcomeExhausted:
+		return "exhausted"
+	default:
+		return "failed"
+	}
+}
+
+// RetryPolicy: drop -> giới hạn bởi ttl_ms/max_attempts; persistent -> retry mãi với backoff có trần.
+type RetryPolicy struct {
+	Persistent  bool
+	TTL         time.Duration
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+func RetryPolicyFromMode(m cfg.RouteMode) RetryPolicy {
+	if strings.ToLower(m.Type) == "persistent" {
+		return RetryPolicy{
+			Persistent:  true,
+			BaseBackoff: 100 * time.Millisecond,
+			MaxBackoff:  30 * time.Second,
+		}
+	}
+	return RetryPolicy{
+		TTL:         time.Duration(m.TTLms) * time.Millisecond,
+		MaxAttempts: m.MaxAttempts,
+		BaseBackoff: 50 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+	}
+}
+
+// backoff tính thời gian chờ trước lần thử thứ attempt+1 (exponential + jitter 50%).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// Run gọi publish cho tới khi thành công hoặc chạm giới hạn của policy.
+// createdAt là mốc tính TTL (meta.createdAtMs, hoặc lúc nhận message).
+func (p RetryPolicy) Run(ctx context.Context, createdAt time.Time, publish func(context.Context) error) (Outcome, int, error) {
+	var lastErr error
+	attempt := 0
+	for {
+		if !p.Persistent && p.TTL > 0 && time.Since(createdAt) > p.TTL {
+			return OutcomeExpired, attempt, lastErr
+		}
+		attempt++
+		lastErr = publish(ctx)
+		if lastErr == nil {
+			return OutcomeDelivered, attempt, nil
+		}
+		if ctx.Err() != nil {
+			return OutcomeFailed, attempt, lastErr
+		}
+		if !p.Persistent && p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return OutcomeExhausted, attempt, lastErr
+		}
+
+		wait := p.backoff(attempt)
+		if !p.Persistent && p.TTL > 0 {
+			// không ngủ quá hạn TTL
+			if left := p.TTL - time.Since(createdAt); left < wait {
+				wait = max(left, 0)
+			}
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return OutcomeFailed, attempt, lastErr
+		case <-t.C:
+		}
+	}
+}
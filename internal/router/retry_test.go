@@ -0,0 +1,141 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+)
+
+var errNack = errors.New("nack")
+
+// fakePublish: lỗi failures lần đầu rồi thành công; đếm số lần gọi.
+func fakePublish(failures int, calls *int) func(context.Context) error {
+	return func(context.Context) error {
+		*calls++
+		if *calls <= failures {
+			return errNack
+		}
+		return nil
+	}
+}
+
+func TestRetryPolicyFromMode(t *testing.T) {
+	p := RetryPolicyFromMode(cfg.RouteMode{Type: "Persistent", TTLms: 100, MaxAttempts: 3})
+	if !p.Persistent || p.TTL != 0 || p.MaxAttempts != 0 {
+		t.Fatalf("persistent policy = %+v", p)
+	}
+	p = RetryPolicyFromMode(cfg.RouteMode{Type: "drop", TTLms: 1500, MaxAttempts: 3})
+	if p.Persistent || p.TTL != 1500*time.Millisecond || p.MaxAttempts != 3 {
+		t.Fatalf("drop policy = %+v", p)
+	}
+}
+
+func TestRetryPolicyRun(t *testing.T) {
+	fast := RetryPolicy{BaseBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	tests := []struct {
+		name      string
+		policy    func(RetryPolicy) RetryPolicy
+		createdAt time.Duration // trước now
+		failures  int
+		want      Outcome
+		attempts  int
+	}{
+		{"first try", func(p RetryPolicy) RetryPolicy { return p }, 0, 0, OutcomeDelivered, 1},
+		{"after retries", func(p RetryPolicy) RetryPolicy { p.MaxAttempts = 5; return p }, 0, 2, OutcomeDelivered, 3},
+		{"exhausted", func(p RetryPolicy) RetryPolicy { p.MaxAttempts = 3; return p }, 0, 10, OutcomeExhausted, 3},
+		{"expired before first try", func(p RetryPolicy) RetryPolicy { p.TTL = 10 * time.Millisecond; return p }, time.Second, 0, OutcomeExpired, 0},
+		{"expired while retrying", func(p RetryPolicy) RetryPolicy { p.TTL = 20 * time.Millisecond; return p }, 0, 1 << 30, OutcomeExpired, -1},
+		{"persistent ignores limits", func(p RetryPolicy) RetryPolicy {
+			p.Persistent, p.MaxAttempts, p.TTL = true, 2, time.Millisecond
+			return p
+		}, time.Second, 5, OutcomeDelivered, 6},
+	}
+	for _, tt := range tests {
+		calls := 0
+		out, n, err := tt.policy(fast).Run(context.Background(), time.Now().Add(-tt.createdAt), fakePublish(tt.failures, &calls))
+		if out != tt.want {
+			t.Errorf("%s: outcome = %s (err %v), want %s", tt.name, out, err, tt.want)
+		}
+		if tt.attempts >= 0 && n != tt.attempts {
+			t.Errorf("%s: attempts = %d, want %d", tt.name, n, tt.attempts)
+		}
+		if n != calls {
+			t.Errorf("%s: attempts = %d but publish called %d times", tt.name, n, calls)
+		}
+		if out != OutcomeDelivered && tt.failures > 0 && !errors.Is(err, errNack) {
+			t.Errorf("%s: err = %v, want last publish error", tt.name, err)
+		}
+	}
+}
+
+// TTL cắt ngắn thời gian chờ: không ngủ hết backoff khi hạn đã gần.
+func TestRetryPolicyTTLCutsBackoff(t *testing.T) {
+	p := RetryPolicy{TTL: 30 * time.Millisecond, BaseBackoff: time.Second, MaxBackoff: time.Second}
+	calls := 0
+	start := time.Now()
+	out, _, _ := p.Run(context.Background(), start, fakePublish(1<<30, &calls))
+	if out != OutcomeExpired {
+		t.Fatalf("outcome = %s, want expired", out)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatalf("Run took %s, backoff should stop at the TTL", d)
+	}
+}
+
+func TestRetryPolicyBackoffBounds(t *testing.T) {
+	p := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, ceil := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second, // 1.6s bị chặn ở MaxBackoff
+		50: time.Second,
+	} {
+		for i := 0; i < 200; i++ {
+			d := p.backoff(attempt)
+			if d < ceil/2 || d > ceil {
+				t.Fatalf("backoff(%d) = %s, want in [%s, %s]", attempt, d, ceil/2, ceil)
+			}
+		}
+	}
+	if d := (RetryPolicy{}).backoff(3); d != 0 {
+		t.Fatalf("zero policy backoff = %s", d)
+	}
+}
+
+func TestRetryPolicyPersistentStopsOnCancel(t *testing.T) {
+	p := RetryPolicyFromMode(cfg.RouteMode{Type: "persistent"})
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	time.AfterFunc(30*time.Millisecond, cancel)
+
+	done := make(chan Outcome, 1)
+	go func() {
+		out, _, _ := p.Run(ctx, time.Now(), func(context.Context) error { calls++; return errNack })
+		done <- out
+	}()
+	select {
+	case out := <-done:
+		if out != OutcomeFailed {
+			t.Fatalf("outcome = %s, want failed", out)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("persistent Run did not stop on context cancel")
+	}
+	if calls == 0 {
+		t.Fatal("publish never called")
+	}
+}
+
+// ctx bị hủy trong lúc publish: Failed ngay, không retry.
+func TestRetryPolicyCancelDuringPublish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := RetryPolicy{MaxAttempts: 5, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	out, n, _ := p.Run(ctx, time.Now(), func(context.Context) error { cancel(); return errNack })
+	if out != OutcomeFailed || n != 1 {
+		t.Fatalf("outcome = %s after %d attempts, want failed after 1", out, n)
+	}
+}
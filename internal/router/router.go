@@ -9,8 +9,13 @@ import (
 	"time"
 
 	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	core "github.com/cuongceg/validate_yaml/internal/core"
+	"github.com/cuongceg/validate_yaml/internal/dedup"
+	"github.com/cuongceg/validate_yaml/internal/expr"
 	util "github.com/cuongceg/validate_yaml/internal/util"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ====== Message model trung gian ======
@@ -39,36 +44,86 @@ type PayloadCodec interface {
 	ContentType() string
 }
 
+// kết quả nội bộ của handler: message đã xử lý xong (ack) nhưng không tới target
+var (
+	errDeadLettered = errors.New("dead-lettered")
+	errDropped      = errors.New("dropped")
+	errRejected     = errors.New("rejected")
+	errDuplicate    = errors.New("duplicate")
+	errLooped       = errors.New("looped")
+)
+
+// deliveryResult là kết quả lane worker báo về handler của ingress.
+type deliveryResult struct {
+	target   string
+	outcome  Outcome
+	attempts int
+	err      error
+}
+
 // ====== Filter/Projection ======
 type FilterFn func(ctx context.Context, msg *Message, obj map[string]any) (bool, error)
 type ProjectFn func(ctx context.Context, msg *Message, obj map[string]any) (map[string]any, error)
 
 type Engine struct {
-	Buses          map[string]Bus
-	CodecsBySource PayloadCodec
-	Filters        map[string]FilterFn
-	Projections    map[string]ProjectFn
-	Logger         func(format string, args ...any)
+	Buses        map[string]Bus
+	Codecs       map[string]PayloadCodec // theo tên (ingress.codec / egress.codec)
+	DefaultCodec PayloadCodec            // dùng khi ingress không khai báo codec
+	Filters      map[string]FilterFn
+	Projections  map[string]ProjectFn
+	Schemas      map[string]ValidateFn
+	Transforms   map[string]TransformFn
+	Dedup        dedup.Store // store cho route có dedup; nil -> Redis (rdb của StartRoutes)
+	Logger       func(format string, args ...any)
 
 	// tune
 	LanesPerTarget int // số lane publish mỗi target (mặc định 8)
 	LaneBuffer     int // độ sâu buffer mỗi lane (mặc định 20000)
+
+	counters map[string]*RouteCounters
+	depths   map[string]func() map[string]int // route -> độ sâu hàng đợi theo target
+	states   []*routeState                    // theo thứ tự route trong config
 }
 
 func (e *Engine) logf(format string, args ...any) {
 	util.App.Printf(format, args...)
 }
 
+// codec tra cứu codec theo tên; "" -> DefaultCodec (có thể nil: không decode).
+func (e *Engine) codec(name string) (PayloadCodec, error) {
+	if name == "" {
+		return e.DefaultCodec, nil
+	}
+	c, ok := e.Codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("codec %q not registered", name)
+	}
+	return c, nil
+}
+
 func (e *Engine) StartRoutes(ctx context.Context, uc *cfg.UserConfig, rdb *redis.Client) (stop func(), err error) {
 	if e.Buses == nil {
 		return nil, errors.New("router.Engine: Buses is nil")
 	}
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(ctx)
+	e.counters = make(map[string]*RouteCounters, len(uc.Routes))
+	e.depths = make(map[string]func() map[string]int, len(uc.Routes))
+	e.states = make([]*routeState, 0, len(uc.Routes))
 
 	// defaults
-	lanes := 16
-	laneBuf := 8192
+	lanes := e.LanesPerTarget
+	if lanes <= 0 {
+		lanes = 8
+	}
+	laneBuf := e.LaneBuffer
+	if laneBuf <= 0 {
+		laneBuf = 20000
+	}
+	dedupStore := e.Dedup
+	if dedupStore == nil && rdb != nil {
+		dedupStore = dedup.NewRedisStore(rdb, "")
+	}
 
 	// Map group_receivers
 	type toTarget struct{ Connector, Target string }
@@ -81,44 +136,178 @@ func (e *Engine) StartRoutes(ctx context.Context, uc *cfg.UserConfig, rdb *redis
 		groupTargets[g.Name] = targets
 	}
 
+	// codec theo ingress/egress
+	inCodecName := make(map[toTarget]string)
+	outCodecName := make(map[toTarget]string)
+	for _, c := range uc.Connectors {
+		for _, in := range c.Ingress {
+			inCodecName[toTarget{Connector: c.Name, Target: in.SourceName}] = in.Codec
+		}
+		for _, eg := range c.Egress {
+			outCodecName[toTarget{Connector: c.Name, Target: eg.Name}] = eg.Codec
+		}
+	}
+
+	// {payload.*} trong template của egress: router đưa giá trị vào meta trước khi publish
+	// (chỉ để render template, egress không gửi thành header: core.Headers)
+	payloadRefs := make(map[toTarget][]string)
+	for _, c := range uc.Connectors {
+		for _, eg := range c.Egress {
+			for _, src := range []string{eg.TopicTemplate, eg.SubjectTemplate, eg.RoutingKeyTemplate} {
+				if tpl, err := expr.ParseTemplate(src); err == nil {
+					key := toTarget{Connector: c.Name, Target: eg.Name}
+					payloadRefs[key] = append(payloadRefs[key], tpl.PayloadPaths()...)
+				}
+			}
+		}
+	}
+
+	// filter đọc payload.* cần codec decode được (raw/không codec -> mọi message bị lọc)
+	filterReadsPayload := payloadFilters(uc.Filters)
+
+	// route theo nguồn (connector/source_name), giữ thứ tự xuất hiện
+	bindings := make(map[toTarget][]routeBinding)
+	var sources []toTarget
+
 	// Duyệt routes theo YAML
 	for _, r := range uc.Routes {
 		fromConn := r.From.Connector
 		fromSrc := r.From.Source
 
-		// Danh sách đích
+		// Danh sách đích: với switch là hợp của đích mọi case; mỗi case giữ index vào targets
 		var targets []toTarget
-		if r.ToGroup != "" {
-			ts, ok := groupTargets[r.ToGroup]
-			if !ok {
-				cancel()
-				return nil, fmt.Errorf("route %q: to_group %q not found", r.Name, r.ToGroup)
+		targetIdx := map[toTarget]int{}
+		resolve := func(to *cfg.RouteEndpoint, toGroup string) ([]int, error) {
+			var ts []toTarget
+			if toGroup != "" {
+				g, ok := groupTargets[toGroup]
+				if !ok {
+					return nil, fmt.Errorf("to_group %q not found", toGroup)
+				}
+				ts = g
+			} else if to != nil {
+				ts = []toTarget{{Connector: to.Connector, Target: to.Target}}
+			}
+			idx := make([]int, 0, len(ts))
+			for _, t := range ts {
+				ti, ok := targetIdx[t]
+				if !ok {
+					ti = len(targets)
+					targetIdx[t] = ti
+					targets = append(targets, t)
+				}
+				idx = append(idx, ti)
+			}
+			return idx, nil
+		}
+
+		type switchCase struct {
+			when *expr.Program
+			idx  []int
+		}
+		var cases []switchCase
+		var defaultIdx, allIdx []int // defaultIdx nil: không case nào khớp -> lọc
+		if r.Switch != nil {
+			for ci, c := range r.Switch.Cases {
+				prg, err := expr.Compile(c.When)
+				if err != nil {
+					cancel()
+					return nil, fmt.Errorf("route %q: switch.cases[%d].when: %w", r.Name, ci, err)
+				}
+				idx, err := resolve(c.To, c.ToGroup)
+				if err != nil {
+					cancel()
+					return nil, fmt.Errorf("route %q: switch.cases[%d]: %w", r.Name, ci, err)
+				}
+				cases = append(cases, switchCase{when: prg, idx: idx})
+			}
+			if d := r.Switch.Default; d != nil {
+				idx, err := resolve(d.To, d.ToGroup)
+				if err != nil {
+					cancel()
+					return nil, fmt.Errorf("route %q: switch.default: %w", r.Name, err)
+				}
+				defaultIdx = idx
 			}
-			targets = ts
 		} else {
-			targets = []toTarget{{Connector: r.To.Connector, Target: r.To.Target}}
+			idx, err := resolve(r.To, r.ToGroup)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("route %q: %w", r.Name, err)
+			}
+			allIdx = idx
+		}
+
+		type payloadRef struct {
+			key  string
+			segs []string
+		}
+		var refs []payloadRef
+		seenRef := map[string]bool{}
+		for _, tgt := range targets {
+			for _, ref := range payloadRefs[tgt] {
+				if seenRef[ref] {
+					continue
+				}
+				seenRef[ref] = true
+				_, segs, _ := expr.SplitPath(ref)
+				refs = append(refs, payloadRef{key: ref, segs: segs})
+			}
 		}
 
-		inBus, ok := e.Buses[fromConn]
-		if !ok {
+		if _, ok := e.Buses[fromConn]; !ok {
 			cancel()
 			return nil, fmt.Errorf("route %q: connector %q not found", r.Name, fromConn)
 		}
 
-		var codec PayloadCodec
-		if e.CodecsBySource != nil {
-			codec = e.CodecsBySource
+		// codec của route (nếu có) ưu tiên hơn codec khai báo ở ingress
+		codecName := inCodecName[toTarget{Connector: fromConn, Target: fromSrc}]
+		if r.Codec != "" {
+			codecName = r.Codec
+		}
+		codec, err := e.codec(codecName)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("route %q: source %s/%s: %w", r.Name, fromConn, fromSrc, err)
+		}
+		// codec đầu ra theo target (route.output_codec > egress.codec); nil = giữ bytes như nguồn
+		outCodecs := make([]PayloadCodec, len(targets))
+		for ti, tgt := range targets {
+			name := outCodecName[tgt]
+			if r.OutputCodec != "" {
+				name = r.OutputCodec
+			}
+			if name == "" {
+				continue
+			}
+			oc, err := e.codec(name)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("route %q: target %s/%s: %w", r.Name, tgt.Connector, tgt.Target, err)
+			}
+			if oc == codec || passthrough(oc) {
+				continue
+			}
+			if codec == nil || passthrough(codec) {
+				cancel()
+				return nil, fmt.Errorf("route %q: target %s/%s uses codec %q but source %s/%s has no codec to decode", r.Name, tgt.Connector, tgt.Target, name, fromConn, fromSrc)
+			}
+			outCodecs[ti] = oc
 		}
 
 		// Filters
 		var filterFns []FilterFn
 		for _, name := range r.Filters {
-			if f, ok := e.Filters[name]; ok {
-				filterFns = append(filterFns, f)
-			} else {
+			f, ok := e.Filters[name]
+			if !ok {
 				cancel()
 				return nil, fmt.Errorf("route %q: filter %q not registered", r.Name, name)
 			}
+			if filterReadsPayload[name] && (codec == nil || passthrough(codec)) {
+				cancel()
+				return nil, fmt.Errorf("route %q: filter %q reads payload and needs a decoding codec on source %s/%s", r.Name, name, fromConn, fromSrc)
+			}
+			filterFns = append(filterFns, f)
 		}
 
 		// Projection
@@ -129,32 +318,128 @@ func (e *Engine) StartRoutes(ctx context.Context, uc *cfg.UserConfig, rdb *redis
 				cancel()
 				return nil, fmt.Errorf("route %q: projection %q not registered", r.Name, r.Projection)
 			}
+			if codec == nil || passthrough(codec) {
+				cancel()
+				return nil, fmt.Errorf("route %q: projection %q needs a decoding codec on source %s/%s", r.Name, r.Projection, fromConn, fromSrc)
+			}
 			project = p
 		}
 
+		// Transform
+		var transform TransformFn
+		if r.Transform != "" {
+			t, ok := e.Transforms[r.Transform]
+			if !ok {
+				cancel()
+				return nil, fmt.Errorf("route %q: transform %q not registered", r.Name, r.Transform)
+			}
+			if codec == nil || passthrough(codec) {
+				cancel()
+				return nil, fmt.Errorf("route %q: transform %q needs a decoding codec on source %s/%s", r.Name, r.Transform, fromConn, fromSrc)
+			}
+			transform = t
+		}
+
+		// Schema validation
+		var validate ValidateFn
+		if r.Schema != "" {
+			v, ok := e.Schemas[r.Schema]
+			if !ok {
+				cancel()
+				return nil, fmt.Errorf("route %q: schema %q not registered", r.Name, r.Schema)
+			}
+			if codec == nil || passthrough(codec) {
+				cancel()
+				return nil, fmt.Errorf("route %q: schema %q needs a decoding codec on source %s/%s", r.Name, r.Schema, fromConn, fromSrc)
+			}
+			validate = v
+		}
+		var reject *deadLetter
+		if r.Reject != nil {
+			rjBus, ok := e.Buses[r.Reject.Connector]
+			if !ok {
+				cancel()
+				return nil, fmt.Errorf("route %q: reject connector %q not found", r.Name, r.Reject.Connector)
+			}
+			reject = newDeadLetter(rjBus, rejectKeys, r.Reject.Connector, r.Reject.Target, r.Name, fromConn, fromSrc)
+		}
+
+		// Dead-letter
+		var dlq *deadLetter
+		if r.DeadLetter != nil {
+			dlBus, ok := e.Buses[r.DeadLetter.Connector]
+			if !ok {
+				cancel()
+				return nil, fmt.Errorf("route %q: dead_letter connector %q not found", r.Name, r.DeadLetter.Connector)
+			}
+			dlq = newDeadLetter(dlBus, dlqKeys, r.DeadLetter.Connector, r.DeadLetter.Target, r.Name, fromConn, fromSrc)
+		}
+
+		counters := &RouteCounters{}
+		e.counters[r.Name] = counters
+		state := &routeState{name: r.Name, from: fromConn + "/" + fromSrc, counters: counters, state: RouteStarting}
+		for _, t := range targets {
+			state.targets = append(state.targets, t.Connector+"/"+t.Target)
+		}
+		e.states = append(e.states, state)
+
 		// Mode
 		mode := strings.ToLower(r.Mode.Type) // "persistent" | "drop" | ...
-		var ttlMs int64 = 0
-		var maxAttempts int = 0
-		if mode == "drop" {
-			ttlMs = r.Mode.TTLms
-			maxAttempts = r.Mode.MaxAttempts
+		policy := RetryPolicyFromMode(r.Mode)
+
+		// Ordering: chọn lane theo key / một lane (strict) / round-robin
+		laneSel, err := newLaneSelector(r, lanes)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("route %q: %w", r.Name, err)
+		}
+		if usesPayload(r) && (codec == nil || passthrough(codec)) {
+			cancel()
+			return nil, fmt.Errorf("route %q: partition_key %q needs a decoding codec on source %s/%s", r.Name, r.PartitionKey, fromConn, fromSrc)
+		}
+		routeLanes := laneSel.lanes
+
+		// Dedup: bỏ qua target đã nhận message cùng ID
+		var dd *deduper
+		if r.Dedup != nil {
+			dd, err = newDeduper(dedupStore, r.Name, r.Dedup, policy.TTL)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("route %q: %w", r.Name, err)
+			}
+			if usesPayloadID(r) && (codec == nil || passthrough(codec)) {
+				cancel()
+				return nil, fmt.Errorf("route %q: dedup.id_from %q needs a decoding codec on source %s/%s", r.Name, r.Dedup.IDFrom, fromConn, fromSrc)
+			}
 		}
 
 		// === Worker pool per-target ===
 		type job struct {
-			msg  *Message
-			done chan error // báo về để commit offset sau khi publish OK
+			msg       *Message
+			createdAt time.Time
+			span      trace.SpanContext     // span của route, làm cha cho span publish
+			done      chan deliveryResult   // báo về để commit offset sau khi publish OK
+			refresh   func(context.Context) // gia hạn claim dedup trước mỗi lần retry (nil nếu không claim)
 		}
 		// lanes[targetIndex][laneIndex] -> chan job
 		lanesPerTarget := make([][]chan job, len(targets))
 		for ti := range targets {
-			lanesPerTarget[ti] = make([]chan job, lanes)
-			for li := 0; li < lanes; li++ {
+			lanesPerTarget[ti] = make([]chan job, routeLanes)
+			for li := 0; li < routeLanes; li++ {
 				lanesPerTarget[ti][li] = make(chan job, laneBuf)
 			}
 		}
 
+		e.depths[r.Name] = func() map[string]int {
+			out := make(map[string]int, len(targets))
+			for ti, tgt := range targets {
+				for _, q := range lanesPerTarget[ti] {
+					out[tgt.Connector+"/"+tgt.Target] += len(q)
+				}
+			}
+			return out
+		}
+
 		// Spin worker cho từng lane/target
 		for ti, tgt := range targets {
 			outBus := e.Buses[tgt.Connector]
@@ -162,149 +447,420 @@ func (e *Engine) StartRoutes(ctx context.Context, uc *cfg.UserConfig, rdb *redis
 				cancel()
 				return nil, fmt.Errorf("route %q: target connector %q not found", r.Name, tgt.Connector)
 			}
-			for li := 0; li < lanes; li++ {
+			for li := 0; li < routeLanes; li++ {
 				wg.Add(1)
 				go func(routeName string, tgt toTarget, laneIdx int, q <-chan job) {
 					defer wg.Done()
 					for j := range q {
-						var err error
-						//attempt := 0
-						for {
-							// Publish blocking; exgress sẽ xử lý confirm/return
-							err = outBus.Publish(ctx, tgt.Target, j.msg)
-							break
-							// if err == nil {
-							// 	break
-							// }
-							// if ttlMs > 0 {
-							// 	created, _ := getInt64(j.msg.Meta, "createdAtMs")
-							// 	age := time.Now().UnixMilli() - created
-							// 	if created > 0 && age > ttlMs {
-							// 		err = nil // coi như drop-success để không giữ offset mãi
-							// 		break
-							// 	}
-							// }
-							// attempt++
-							// if maxAttempts > 0 && attempt >= maxAttempts {
-							// 	// hết nỗ lực
-							// 	break
-							// }
-							// time.Sleep(50 * time.Millisecond)
-							// continue
+						// Publish blocking; exgress sẽ xử lý confirm/return
+						pctx, sp := tracer.Start(trace.ContextWithSpanContext(ctx, j.span), "publish "+tgt.Connector+"/"+tgt.Target,
+							trace.WithAttributes(attribute.Int("bridge.lane", laneIdx)))
+						tries := 0
+						outcome, attempts, err := policy.Run(pctx, j.createdAt, func(ctx context.Context) error {
+							// route persistent retry không giới hạn: giữ claim sống qua từng lần thử
+							if tries++; tries > 1 && j.refresh != nil {
+								j.refresh(ctx)
+							}
+							return outBus.Publish(ctx, tgt.Target, j.msg)
+						})
+						if err != nil && outcome == OutcomeDelivered {
+							err = nil
+						}
+						endSpan(sp, err, attribute.String("bridge.outcome", outcome.String()), attribute.Int("bridge.attempts", attempts))
+						if attempts > 1 {
+							counters.Retried.Add(uint64(attempts - 1))
 						}
-						j.done <- err
+						if outcome == OutcomeDelivered {
+							counters.Published.Add(1)
+						}
+						if attempts > 1 || outcome != OutcomeDelivered {
+							e.logf("[route=%s] %s/%s: %s after %d attempt(s): %v", routeName, tgt.Connector, tgt.Target, outcome, attempts, err)
+						}
+						j.done <- deliveryResult{outcome: outcome, attempts: attempts, err: err}
 					}
 				}(r.Name, tgt, li, lanesPerTarget[ti][li])
 			}
 		}
 
-		wg.Add(1)
-		go func(routeName string, fromSrc string, inBus Bus) {
-			fmt.Printf("[route=%s] start: from %s/%s to %d targets, lanes=%d, mode=%s, ttlMs=%d, maxAttempts=%d\n",
-				routeName, fromConn, fromSrc, len(targets), lanes, mode, ttlMs, maxAttempts)
-			defer wg.Done()
+		routeName := r.Name
+		hop := fromConn + "/" + fromSrc
+		maxHops := r.MaxHops
+		if maxHops <= 0 {
+			maxHops = defaultMaxHops
+		}
+		fmt.Printf("[route=%s] start: from %s/%s to %d targets, lanes=%d, ordering=%s, mode=%s, ttl=%s, maxAttempts=%d\n",
+			routeName, fromConn, fromSrc, len(targets), routeLanes, orderingOf(r), mode, policy.TTL, policy.MaxAttempts)
 
-			// Subscribe nguồn; handler sẽ:
-			// 1) decode/filter/project
-			// 2) gửi job tới đúng lane của từng target
-			// 3) CHỜ all targets ok -> return nil -> ingress commit offset
-			if err := inBus.Subscribe(ctx, fromSrc, func(ctx context.Context, in *Message) error {
-				//Decode (nếu có)
-				// msg_id, has := in.Meta["msg_id"].(string)
-				// if has {
-				// 	ok, hopErr := util.CheckHop(ctx, rdb, msg_id, fromConn, fromSrc, time.Minute*10)
-				// 	if !ok {
-				// 		e.logf("[route=%s] drop msg_id=%s due to loop hop with error %s", routeName, in.Meta["msg_id"].(string), hopErr)
-				// 		return nil
-				// 	}
-				// } else {
-				// 	e.logf("[route=%s] warning: message without msg_id in meta", routeName)
-				// }
-				var obj map[string]any
-				var domain any
-				var err error
-				if codec != nil && len(in.Value) > 0 {
-					obj, domain, err = codec.Decode(in.Value)
-					if err != nil {
-						e.logf("[route=%s] decode error: %v", routeName, err)
-						return fmt.Errorf("decode failed: %w", err)
-					}
+		// Handler của route (nguồn subscribe chung, xem fanOut):
+		// 1) decode/filter/project
+		// 2) gửi job tới đúng lane của từng target
+		// 3) CHỜ all targets ok -> return nil -> ingress commit offset
+		handle := func(ctx context.Context, in *Message) error {
+			// meta
+			if in.Meta == nil {
+				in.Meta = map[string]any{}
+			}
+			// hop: bỏ (ack) message đã đi qua nguồn này hoặc quá max_hops
+			hops := hopsOf(in.Meta)
+			if herr := checkHop(hops, hop, maxHops); herr != nil {
+				e.logf("[route=%s] drop msg_id=%v: %v", routeName, in.Meta[MetaMsgID], herr)
+				return errLooped
+			}
+			// giữ bytes/meta gốc cho dead-letter
+			orig := in.Value
+			origMeta := make(map[string]any, len(in.Meta))
+			for k, v := range in.Meta {
+				origMeta[k] = v
+			}
+			// failed: gửi DLQ nếu có, thành công thì ack; không có DLQ -> trả lỗi như cũ
+			failed := func(target string, attempts int, cause error) error {
+				if dlq == nil {
+					return cause
 				}
-				// meta
-				if in.Meta == nil {
-					in.Meta = map[string]any{}
+				if err := dlq.send(ctx, orig, origMeta, target, cause.Error(), attempts); err != nil {
+					e.logf("[route=%s] dead-letter %s/%s failed: %v", routeName, dlq.connector, dlq.target, err)
+					return cause
 				}
+				e.logf("[route=%s] dead-lettered to %s/%s: %v", routeName, dlq.connector, dlq.target, cause)
+				return errDeadLettered
+			}
+
+			var obj map[string]any
+			var domain any
+			var err error
+			if codec != nil && !passthrough(codec) && len(in.Value) > 0 {
+				_, sp := tracer.Start(ctx, "decode")
+				obj, domain, err = codec.Decode(in.Value)
+				endSpan(sp, err, attribute.String("bridge.codec", codec.ContentType()))
+				if err != nil {
+					e.logf("[route=%s] decode error: %v", routeName, err)
+					return failed("", 0, fmt.Errorf("decode failed: %w", err))
+				}
+			}
+
+			in.Meta["route"] = routeName
+			stampHop(in.Meta, hops, hop)
+			if codec != nil && !passthrough(codec) {
+				in.Meta["content-type"] = codec.ContentType()
+			}
+			for _, ref := range refs {
+				if v, ok := expr.Lookup(obj, ref.segs); ok {
+					in.Meta[ref.key] = v
+				}
+			}
 
-				// filter
+			// filter
+			if len(filterFns) > 0 {
+				fctx, sp := tracer.Start(ctx, "filter")
+				keep, ferr := true, error(nil)
 				for _, f := range filterFns {
-					ok, ferr := f(ctx, in, obj)
-					if ferr != nil {
-						e.logf("[route=%s] filter error: %v", routeName, ferr)
-						return ferr
+					if keep, ferr = f(fctx, in, obj); ferr != nil || !keep {
+						break
+					}
+				}
+				endSpan(sp, ferr, attribute.Bool("bridge.filter.pass", keep && ferr == nil))
+				if ferr != nil {
+					e.logf("[route=%s] filter error: %v", routeName, ferr)
+					return failed("", 0, fmt.Errorf("filter failed: %w", ferr))
+				}
+				if !keep {
+					return core.ErrFiltered
+				}
+			}
+
+			// switch: chọn đích theo payload đã decode (trước transform)
+			selected := allIdx
+			if r.Switch != nil {
+				selected = defaultIdx
+				for _, c := range cases {
+					ok, werr := c.when.Eval(obj, in.Meta)
+					if werr != nil && !expr.IsMissingField(werr) {
+						e.logf("[route=%s] switch error: %v", routeName, werr)
+						return failed("", 0, fmt.Errorf("switch failed: %w", werr))
 					}
-					if !ok {
-						return fmt.Errorf("filtered")
+					if ok {
+						selected = c.idx
+						break
 					}
 				}
+				if selected == nil {
+					return core.ErrFiltered
+				}
+			}
 
-				// projection
-				if project != nil && codec != nil {
-					newObj, perr := project(ctx, in, obj)
-					if perr != nil {
-						e.logf("[route=%s] projection error: %v", routeName, perr)
-						return perr
+			// schema (chỉ message route sẽ giao, sau filter/switch): không hợp lệ -> reject target,
+			// không có thì dead_letter, không có nữa thì bỏ (ack)
+			if validate != nil {
+				vctx, sp := tracer.Start(ctx, "validate")
+				verr := validate(vctx, in, obj)
+				endSpan(sp, verr)
+				if verr != nil {
+					if reject == nil {
+						if dlq != nil {
+							return failed("", 0, verr)
+						}
+						e.logf("[route=%s] rejected (no reject target): %v", routeName, verr)
+						return errRejected
 					}
-					enc, eerr := codec.Encode(newObj, domain)
-					if eerr != nil {
-						e.logf("[route=%s] encode error: %v", routeName, eerr)
-						return eerr
+					if err := reject.send(ctx, orig, origMeta, "", verr.Error(), 0); err != nil {
+						e.logf("[route=%s] reject %s/%s failed: %v", routeName, reject.connector, reject.target, err)
+						return verr
 					}
-					in.Value = enc
+					return errRejected
+				}
+			}
+
+			// lane theo partition key (tính trên payload đã decode, trước transform)
+			laneIdx := laneSel.pick(in.Meta, obj)
+
+			// message ID cho dedup (trên message gốc, trước transform)
+			var msgID string
+			hasID := false
+			if dd != nil {
+				msgID, hasID = dd.id(in.Meta, obj, orig)
+			}
+
+			// transform (sau filter, trước projection)
+			if transform != nil {
+				tctx, sp := tracer.Start(ctx, "transform")
+				newObj, terr := transform(tctx, in, obj)
+				endSpan(sp, terr)
+				if terr != nil {
+					e.logf("[route=%s] transform error: %v", routeName, terr)
+					return failed("", 0, fmt.Errorf("transform failed: %w", terr))
+				}
+				obj = newObj
+			}
+
+			// projection
+			if project != nil {
+				pctx, sp := tracer.Start(ctx, "project")
+				newObj, perr := project(pctx, in, obj)
+				endSpan(sp, perr, attribute.Bool("bridge.project.dropped", perr == nil && newObj == nil))
+				if perr != nil {
+					e.logf("[route=%s] projection error: %v", routeName, perr)
+					return failed("", 0, fmt.Errorf("projection failed: %w", perr))
+				}
+				if newObj == nil {
+					// projection strict + on_missing_field=drop
+					return core.ErrFiltered
+				}
+				obj = newObj
+			}
+
+			// encode lại khi payload đã bị transform/projection thay đổi
+			if transform != nil || project != nil {
+				enc, eerr := codec.Encode(obj, domain)
+				if eerr != nil {
+					e.logf("[route=%s] encode error: %v", routeName, eerr)
+					return failed("", 0, fmt.Errorf("encode failed: %w", eerr))
 				}
+				in.Value = enc
+			}
+
+			// transcode cho các target có codec riêng
+			outMsgs := make([]*Message, len(targets))
+			for _, ti := range selected {
+				oc := outCodecs[ti]
+				if oc == nil {
+					outMsgs[ti] = in
+					continue
+				}
+				enc, eerr := oc.Encode(obj, nil)
+				if eerr != nil {
+					e.logf("[route=%s] encode error for %s/%s: %v", routeName, targets[ti].Connector, targets[ti].Target, eerr)
+					return failed(targets[ti].Connector+"/"+targets[ti].Target, 0, fmt.Errorf("encode failed: %w", eerr))
+				}
+				meta := make(map[string]any, len(in.Meta)+1)
+				for k, v := range in.Meta {
+					meta[k] = v
+				}
+				meta["content-type"] = oc.ContentType()
+				outMsgs[ti] = &Message{Value: enc, Meta: meta}
+			}
 
-				// Publish tới tất cả targets qua lanes và CHỜ kết quả,
-				// để đảm bảo commit offset chỉ sau khi downstream OK.
-				var wgPub sync.WaitGroup
-				errs := make(chan error, len(targets))
-				for ti, tgt := range targets {
-					wgPub.Add(1)
-					go func(ti int, tgt toTarget) {
-						defer wgPub.Done()
-						j := job{msg: in, done: make(chan error, 1)}
-						li := pickLaneIndex(lanes)
-						lanesPerTarget[ti][li] <- j
-						err := <-j.done
-						if err != nil {
-							errs <- fmt.Errorf("%s/%s: %w", tgt.Connector, tgt.Target, err)
-						} else {
-							errs <- nil
+			// Publish tới tất cả targets qua lanes và CHỜ kết quả,
+			// để đảm bảo commit offset chỉ sau khi downstream OK.
+			createdAt := time.Now()
+			if ms, ok := getInt64(in.Meta, "createdAtMs"); ok && ms > 0 {
+				createdAt = time.UnixMilli(ms)
+			}
+			var wgPub sync.WaitGroup
+			results := make(chan deliveryResult, len(selected))
+			pending := 0
+			for _, ti := range selected {
+				tgt := targets[ti]
+				tgtName := tgt.Connector + "/" + tgt.Target
+				claimed := false
+				if hasID {
+					ok, cerr := dd.claim(ctx, tgtName, msgID)
+					if cerr != nil {
+						e.logf("[route=%s] dedup claim %s failed, delivering anyway: %v", routeName, tgtName, cerr)
+					} else if !ok {
+						counters.DedupHits.Add(1)
+						continue
+					} else {
+						claimed = true
+					}
+					counters.DedupMisses.Add(1)
+				}
+				pending++
+				wgPub.Add(1)
+				go func(ti int, tgt toTarget) {
+					defer wgPub.Done()
+					j := job{msg: outMsgs[ti], createdAt: createdAt, span: trace.SpanContextFromContext(ctx), done: make(chan deliveryResult, 1)}
+					if claimed {
+						j.refresh = func(ctx context.Context) {
+							if rerr := dd.refresh(ctx, tgtName, msgID); rerr != nil {
+								e.logf("[route=%s] dedup refresh %s failed: %v", routeName, tgtName, rerr)
+							}
+						}
+					}
+					lanesPerTarget[ti][laneIdx] <- j
+					res := <-j.done
+					res.target = tgtName
+					if res.err != nil {
+						res.err = fmt.Errorf("%s/%s: %w", tgt.Connector, tgt.Target, res.err)
+					}
+					if claimed {
+						// ctx có thể đã hủy (shutdown): vẫn ghi/nhả key
+						sctx := context.WithoutCancel(ctx)
+						if res.outcome == OutcomeDelivered {
+							if merr := dd.mark(sctx, tgtName, msgID); merr != nil {
+								e.logf("[route=%s] dedup mark %s failed: %v", routeName, tgtName, merr)
+							}
+						} else if rerr := dd.release(sctx, tgtName, msgID); rerr != nil {
+							e.logf("[route=%s] dedup release %s failed: %v", routeName, tgtName, rerr)
 						}
-					}(ti, tgt)
+					}
+					results <- res
+				}(ti, tgt)
+			}
+			wgPub.Wait()
+			close(results)
+			if pending == 0 {
+				// mọi target đã nhận message này trước đó
+				return errDuplicate
+			}
+
+			// tổng hợp: expired (mode drop) coi như đã xử lý -> ack;
+			// exhausted -> dead-letter nếu có;
+			// failed -> trả lỗi để ingress không commit, sẽ retry theo Kafka group / requeue
+			var firstErr, dropped error
+			for res := range results {
+				switch res.outcome {
+				case OutcomeFailed:
+					if res.err == nil {
+						res.err = ctx.Err()
+					}
+					if firstErr == nil {
+						firstErr = res.err
+					}
+				case OutcomeExpired:
+					dropped = errDropped
+				case OutcomeExhausted:
+					if dlq == nil {
+						dropped = errDropped
+						continue
+					}
+					err := failed(res.target, res.attempts, res.err)
+					if errors.Is(err, errDeadLettered) {
+						dropped = err
+					} else if firstErr == nil {
+						firstErr = err
+					}
 				}
-				wgPub.Wait()
-				close(errs)
+			}
+			if firstErr != nil {
+				return firstErr
+			}
+
+			return dropped
+		}
 
-				// tổng hợp
-				for e2 := range errs {
-					if e2 != nil {
-						return e2 // khiến ingress không commit; sẽ retry theo Kafka group
+		// handler theo route: đếm kết quả; lỗi "đã xử lý" (dead-letter, drop, reject) -> nil để ingress ack
+		routeHandle := func(ctx context.Context, in *Message) error {
+			counters.Received.Add(1)
+			ctx, sp := tracer.Start(ctx, "route "+routeName, trace.WithAttributes(attribute.String("bridge.route", routeName)))
+			err := handle(ctx, in)
+			ret, result := error(nil), "delivered"
+			switch {
+			case err == nil:
+				counters.Delivered.Add(1)
+			case errors.Is(err, core.ErrFiltered):
+				counters.Filtered.Add(1)
+				ret, result = err, "filtered"
+			case errors.Is(err, errDeadLettered):
+				counters.DeadLettered.Add(1)
+				result = "dead_lettered"
+			case errors.Is(err, errDropped):
+				counters.Dropped.Add(1)
+				result = "dropped"
+			case errors.Is(err, errRejected):
+				counters.Rejected.Add(1)
+				result = "rejected"
+			case errors.Is(err, errDuplicate):
+				counters.Duplicates.Add(1)
+				result = "duplicate"
+			case errors.Is(err, errLooped):
+				counters.Looped.Add(1)
+				result = "looped"
+			default:
+				counters.Failed.Add(1)
+				ret, result = err, "failed"
+				state.setError(err)
+			}
+			var spanErr error
+			if result == "failed" {
+				spanErr = err
+			}
+			endSpan(sp, spanErr, attribute.String("bridge.result", result))
+			return ret
+		}
+
+		src := toTarget{Connector: fromConn, Target: fromSrc}
+		if _, ok := bindings[src]; !ok {
+			sources = append(sources, src)
+		}
+		bindings[src] = append(bindings[src], routeBinding{
+			name:   routeName,
+			state:  state,
+			handle: routeHandle,
+			closeLanes: func() {
+				for ti := range lanesPerTarget {
+					for li := range lanesPerTarget[ti] {
+						close(lanesPerTarget[ti][li])
 					}
 				}
+			},
+		})
+	}
 
-				return nil
-			}); err != nil {
-				e.logf("[route=%s] subscribe error on %s/%s: %v", routeName, fromConn, fromSrc, err)
+	// Subscribe mỗi nguồn đúng một lần, fan-out message tới mọi route dùng chung nguồn
+	for _, src := range sources {
+		bs := bindings[src]
+		inBus := e.Buses[src.Connector]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := inBus.Subscribe(ctx, src.Target, fanOut(bs)); err != nil {
+				e.logf("subscribe error on %s/%s: %v", src.Connector, src.Target, err)
+				for _, b := range bs {
+					b.state.setState(RouteFailed, fmt.Errorf("subscribe %s/%s: %w", src.Connector, src.Target, err))
+				}
 				cancel()
-				return
+			} else {
+				for _, b := range bs {
+					b.state.setState(RouteRunning, nil)
+				}
 			}
 			<-ctx.Done()
 			// đóng các lane
-			for ti := range lanesPerTarget {
-				for li := 0; li < lanes; li++ {
-					close(lanesPerTarget[ti][li])
-				}
+			for _, b := range bs {
+				b.closeLanes()
+				b.state.stopped()
 			}
-		}(r.Name, fromSrc, inBus)
+		}()
 	}
 
 	stop = func() {
@@ -314,6 +870,58 @@ func (e *Engine) StartRoutes(ctx context.Context, uc *cfg.UserConfig, rdb *redis
 	return stop, nil
 }
 
+// routeBinding là một route gắn vào nguồn (ingress) mà nó subscribe.
+type routeBinding struct {
+	name       string
+	state      *routeState
+	handle     func(ctx context.Context, in *Message) error
+	closeLanes func()
+}
+
+// fanOut chạy message qua mọi route của cùng một nguồn (mỗi route một bản sao
+// Message vì route ghi meta riêng). Ack chung: chỉ trả nil khi mọi route xử lý
+// xong (delivered/filtered/...); một route lỗi -> trả lỗi để ingress giao lại.
+func fanOut(bs []routeBinding) func(ctx context.Context, in *Message) error {
+	if len(bs) == 1 {
+		return bs[0].handle
+	}
+	return func(ctx context.Context, in *Message) error {
+		errs := make([]error, len(bs))
+		var wg sync.WaitGroup
+		for i, b := range bs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = b.handle(ctx, cloneMessage(in))
+			}()
+		}
+		wg.Wait()
+
+		filtered := 0
+		for i, err := range errs {
+			switch {
+			case err == nil:
+			case errors.Is(err, core.ErrFiltered):
+				filtered++
+			default:
+				return fmt.Errorf("route %q: %w", bs[i].name, err)
+			}
+		}
+		if filtered == len(bs) {
+			return core.ErrFiltered
+		}
+		return nil
+	}
+}
+
+func cloneMessage(in *Message) *Message {
+	meta := make(map[string]any, len(in.Meta))
+	for k, v := range in.Meta {
+		meta[k] = v
+	}
+	return &Message{Value: in.Value, Meta: meta}
+}
+
 func getInt64(m map[string]any, k string) (int64, bool) {
 	if m == nil {
 		return 0, false
@@ -328,10 +936,3 @@ func getInt64(m map[string]any, k string) (int64, bool) {
 	}
 	return 0, false
 }
-
-func pickLaneIndex(lanes int) int {
-	if lanes <= 1 {
-		return 0
-	}
-	return int(time.Now().UnixNano() % int64(lanes))
-}
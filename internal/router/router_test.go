@@ -0,0 +1,109 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	util "github.com/cuongceg/validate_yaml/internal/util"
+)
+
+func TestMain(m *testing.M) {
+	util.App = log.New(io.Discard, "", 0) // không ghi app.log khi chạy test
+	os.Exit(m.Run())
+}
+
+// fakeBus: Subscribe giữ handler để test tự đẩy message, Publish ghi lại message đã gửi.
+type fakeBus struct {
+	mu        sync.Mutex
+	handlers  map[string]func(context.Context, *Message) error
+	published map[string][]*Message // target -> message
+	failures  map[string]int        // target -> số lần Publish lỗi còn lại
+}
+
+func newFakeBus() *fakeBus {
+	return &fakeBus{handlers: map[string]func(context.Context, *Message) error{}, published: map[string][]*Message{}, failures: map[string]int{}}
+}
+
+func (b *fakeBus) Subscribe(_ context.Context, source string, h func(context.Context, *Message) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[source] = h
+	return nil
+}
+
+func (b *fakeBus) Publish(_ context.Context, target string, msg *Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures[target] > 0 {
+		b.failures[target]--
+		return errors.New("target unavailable")
+	}
+	b.published[target] = append(b.published[target], msg)
+	return nil
+}
+
+func (b *fakeBus) Close() error { return nil }
+
+// deliver đẩy message vào handler đã subscribe của source (như ingress).
+func (b *fakeBus) deliver(t *testing.T, source string, value string, meta map[string]any) error {
+	t.Helper()
+	var h func(context.Context, *Message) error
+	// StartRoutes subscribe trong goroutine
+	for deadline := time.Now().Add(time.Second); h == nil; time.Sleep(time.Millisecond) {
+		b.mu.Lock()
+		h = b.handlers[source]
+		b.mu.Unlock()
+		if h == nil && time.Now().After(deadline) {
+			t.Fatalf("no subscriber on %q", source)
+		}
+	}
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	return h(context.Background(), &Message{Value: []byte(value), Meta: meta})
+}
+
+// fail: n lần Publish tới target kế tiếp trả lỗi.
+func (b *fakeBus) fail(target string, n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures[target] = n
+}
+
+func (b *fakeBus) sent(target string) []*Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*Message(nil), b.published[target]...)
+}
+
+// startRoutes chạy engine với một fakeBus tên "bus" (nguồn "in", đích "out"),
+// trả bus để đẩy message; route.From/To được điền nếu để trống.
+func startRoutes(t *testing.T, e *Engine, routes ...cfg.Route) *fakeBus {
+	t.Helper()
+	bus := newFakeBus()
+	e.Buses = map[string]Bus{"bus": bus}
+	if e.DefaultCodec == nil {
+		e.DefaultCodec = NewJSONCodec()
+	}
+	for i := range routes {
+		if routes[i].From.Connector == "" {
+			routes[i].From = cfg.RouteStartpoint{Connector: "bus", Source: "in"}
+		}
+		if routes[i].To == nil && routes[i].ToGroup == "" && routes[i].Switch == nil {
+			routes[i].To = &cfg.RouteEndpoint{Connector: "bus", Target: "out"}
+		}
+	}
+	stop, err := e.StartRoutes(context.Background(), &cfg.UserConfig{Routes: routes}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+	return bus
+}
@@ -0,0 +1,156 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/expr"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ValidateFn kiểm tra payload đã decode; trả *ValidationError nếu không hợp lệ.
+type ValidateFn func(ctx context.Context, msg *Message, obj map[string]any) error
+
+// ValidationError liệt kê lỗi theo vị trí trong payload.
+type ValidationError struct {
+	Schema string
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("schema %q: %s", e.Schema, strings.Join(e.Errors, "; "))
+}
+
+// CompileSchemas dựng ValidateFn theo tên từ schema: (json_schema | protobuf).
+func CompileSchemas(rules []cfg.SchemaRule) (map[string]ValidateFn, error) {
+	out := make(map[string]ValidateFn, len(rules))
+	for _, r := range rules {
+		var fn ValidateFn
+		var err error
+		switch strings.ToLower(r.Type) {
+		case "json_schema":
+			fn, err = compileJSONSchema(r)
+		case "protobuf":
+			fn, err = compileProtoSchema(r)
+		default:
+			err = fmt.Errorf("unknown type %q", r.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("schema %q: %w", r.Name, err)
+		}
+		out[r.Name] = fn
+	}
+	return out, nil
+}
+
+func compileJSONSchema(r cfg.SchemaRule) (ValidateFn, error) {
+	sch, err := jsonschema.NewCompiler().Compile(r.File)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, msg *Message, obj map[string]any) error {
+		// chuẩn hóa về kiểu JSON (int64/[]byte/... của codec -> số/chuỗi JSON)
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return &ValidationError{Schema: r.Name, Errors: []string{err.Error()}}
+		}
+		inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+		if err != nil {
+			return &ValidationError{Schema: r.Name, Errors: []string{err.Error()}}
+		}
+		err = sch.Validate(inst)
+		if err == nil {
+			return nil
+		}
+		verr, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return &ValidationError{Schema: r.Name, Errors: []string{err.Error()}}
+		}
+		var msgs []string
+		for _, u := range verr.BasicOutput().Errors {
+			if u.Error == nil {
+				continue
+			}
+			loc := u.InstanceLocation
+			if loc == "" {
+				loc = "/"
+			}
+			msgs = append(msgs, loc+": "+u.Error.String())
+		}
+		if len(msgs) == 0 {
+			msgs = []string{verr.Error()}
+		}
+		return &ValidationError{Schema: r.Name, Errors: msgs}
+	}, nil
+}
+
+// compileProtoSchema: payload phải map được vào message (không thừa field, đúng kiểu,
+// đủ field required của proto2) và có đủ các path trong required.
+func compileProtoSchema(r cfg.SchemaRule) (ValidateFn, error) {
+	files, err := LoadDescriptorSet(r.DescriptorSet)
+	if err != nil {
+		return nil, err
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(r.Message))
+	if err != nil {
+		return nil, fmt.Errorf("message %q: %w", r.Message, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message", r.Message)
+	}
+	types := dynamicpb.NewTypes(files)
+	type reqPath struct {
+		path string
+		segs []string
+	}
+	var required []reqPath
+	for _, p := range r.Required {
+		_, segs, err := expr.SplitPath(p)
+		if err != nil {
+			return nil, err
+		}
+		required = append(required, reqPath{path: p, segs: segs})
+	}
+
+	return func(ctx context.Context, msg *Message, obj map[string]any) error {
+		var msgs []string
+		b, err := json.Marshal(obj)
+		if err == nil {
+			err = (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(b, dynamicpb.NewMessage(md))
+		}
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+		for _, rp := range required {
+			if v, ok := expr.Lookup(obj, rp.segs); !ok || isZero(v) {
+				msgs = append(msgs, rp.path+": required field missing")
+			}
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+		return &ValidationError{Schema: r.Name, Errors: msgs}
+	}, nil
+}
+
+// isZero: proto3 không phân biệt "không có" với giá trị mặc định nên coi như thiếu.
+func isZero(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
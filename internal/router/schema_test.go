@@ -0,0 +1,98 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/core"
+)
+
+// hasID: schema giả, payload phải có id.
+func hasID(_ context.Context, _ *Message, obj map[string]any) error {
+	if _, ok := obj["id"]; !ok {
+		return &ValidationError{Schema: "order", Errors: []string{"/: missing property id"}}
+	}
+	return nil
+}
+
+func rejectRoute() cfg.Route {
+	return cfg.Route{
+		Name:    "r",
+		Mode:    cfg.RouteMode{Type: "drop"},
+		Filters: []string{"eu"},
+		Schema:  "order",
+		Reject:  &cfg.RouteEndpoint{Connector: "bus", Target: "rejects"},
+	}
+}
+
+func newRejectEngine(t *testing.T) *Engine {
+	t.Helper()
+	filters, err := CompileFilters([]cfg.FilterRule{{Name: "eu", Expr: `payload.region == "eu"`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Engine{Filters: filters, Schemas: map[string]ValidateFn{"order": hasID}}
+}
+
+// message bị filter loại thì không được validate: không vào reject, không tính rejected.
+func TestSchemaRunsAfterFilters(t *testing.T) {
+	e := newRejectEngine(t)
+	bus := startRoutes(t, e, rejectRoute())
+
+	if err := bus.deliver(t, "in", `{"region":"us"}`, nil); !core.Acked(err) {
+		t.Fatal(err)
+	}
+	if n := len(bus.sent("rejects")); n != 0 {
+		t.Fatalf("reject target received %d messages for a filtered message", n)
+	}
+	if n := len(bus.sent("out")); n != 0 {
+		t.Fatalf("published %d, want 0", n)
+	}
+	if st := e.Stats()["r"]; st.Rejected != 0 || st.Filtered != 1 {
+		t.Fatalf("stats = %+v", st)
+	}
+}
+
+func TestSchemaRejectsDeliverableMessage(t *testing.T) {
+	e := newRejectEngine(t)
+	bus := startRoutes(t, e, rejectRoute())
+
+	if err := bus.deliver(t, "in", `{"region":"eu"}`, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.deliver(t, "in", `{"region":"eu","id":1}`, nil); err != nil {
+		t.Fatal(err)
+	}
+	rejected := bus.sent("rejects")
+	if len(rejected) != 1 || rejected[0].Meta[MetaRejectRoute] != "r" || rejected[0].Meta[MetaRejectReason] == nil {
+		t.Fatalf("rejects = %v", rejected)
+	}
+	if n := len(bus.sent("out")); n != 1 {
+		t.Fatalf("published %d, want 1", n)
+	}
+	if st := e.Stats()["r"]; st.Rejected != 1 || st.Delivered != 1 {
+		t.Fatalf("stats = %+v", st)
+	}
+}
+
+// switch không chọn được đích (không default) cũng không validate.
+func TestSchemaRunsAfterSwitch(t *testing.T) {
+	e := &Engine{Schemas: map[string]ValidateFn{"order": hasID}}
+	r := cfg.Route{
+		Name:   "r",
+		Mode:   cfg.RouteMode{Type: "drop"},
+		Schema: "order",
+		Reject: &cfg.RouteEndpoint{Connector: "bus", Target: "rejects"},
+		Switch: &cfg.RouteSwitch{Cases: []cfg.RouteCase{
+			{When: `payload.region == "eu"`, To: &cfg.RouteEndpoint{Connector: "bus", Target: "out"}},
+		}},
+	}
+	bus := startRoutes(t, e, r)
+	if err := bus.deliver(t, "in", `{"region":"us"}`, nil); !core.Acked(err) {
+		t.Fatal(err)
+	}
+	if n := len(bus.sent("rejects")); n != 0 {
+		t.Fatalf("reject target received %d messages for an unrouted message", n)
+	}
+}
@@ -0,0 +1,173 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RouteCounters đếm message theo route; an toàn khi dùng đồng thời.
+type RouteCounters struct {
+	Received     atomic.Uint64
+	Filtered     atomic.Uint64
+	Delivered    atomic.Uint64
+	Published    atomic.Uint64 // theo target: publish thành công
+	Retried      atomic.Uint64 // theo target: số lần publish lại
+	Failed       atomic.Uint64
+	DeadLettered atomic.Uint64
+	Dropped      atomic.Uint64 // expired/exhausted ở mode drop, không có dead_letter
+	Rejected     atomic.Uint64 // không hợp lệ schema (gửi reject target hoặc bỏ)
+	Duplicates   atomic.Uint64 // mọi target đã nhận message cùng ID (dedup)
+	Looped       atomic.Uint64 // đã qua nguồn này (vòng lặp) hoặc quá max_hops
+	DedupHits    atomic.Uint64 // theo target: đã giao trước đó -> bỏ qua
+	DedupMisses  atomic.Uint64 // theo target: chưa giao -> publish
+}
+
+// RouteStats là snapshot của RouteCounters.
+type RouteStats struct {
+	Received     uint64 `json:"received"`
+	Filtered     uint64 `json:"filtered"`
+	Delivered    uint64 `json:"delivered"`
+	Published    uint64 `json:"published"`
+	Retried      uint64 `json:"retried"`
+	Failed       uint64 `json:"failed"`
+	DeadLettered uint64 `json:"dead_lettered"`
+	Dropped      uint64 `json:"dropped"`
+	Rejected     uint64 `json:"rejected"`
+	Duplicates   uint64 `json:"duplicates"`
+	Looped       uint64 `json:"looped"`
+	DedupHits    uint64 `json:"dedup_hits"`
+	DedupMisses  uint64 `json:"dedup_misses"`
+}
+
+func (c *RouteCounters) Snapshot() RouteStats {
+	return RouteStats{
+		Received:     c.Received.Load(),
+		Filtered:     c.Filtered.Load(),
+		Delivered:    c.Delivered.Load(),
+		Published:    c.Published.Load(),
+		Retried:      c.Retried.Load(),
+		Failed:       c.Failed.Load(),
+		DeadLettered: c.DeadLettered.Load(),
+		Dropped:      c.Dropped.Load(),
+		Rejected:     c.Rejected.Load(),
+		Duplicates:   c.Duplicates.Load(),
+		Looped:       c.Looped.Load(),
+		DedupHits:    c.DedupHits.Load(),
+		DedupMisses:  c.DedupMisses.Load(),
+	}
+}
+
+// Stats trả snapshot counters của mọi route đã start.
+func (e *Engine) Stats() map[string]RouteStats {
+	out := make(map[string]RouteStats, len(e.counters))
+	for name, c := range e.counters {
+		out[name] = c.Snapshot()
+	}
+	return out
+}
+
+// LaneDepths trả số message đang chờ trong lanes, theo route rồi theo target (connector/target).
+func (e *Engine) LaneDepths() map[string]map[string]int {
+	out := make(map[string]map[string]int, len(e.depths))
+	for name, fn := range e.depths {
+		out[name] = fn()
+	}
+	return out
+}
+
+// Trạng thái route (admin API).
+const (
+	RouteStarting = "starting" // chờ subscribe nguồn
+	RouteRunning  = "running"
+	RouteFailed   = "failed" // subscribe lỗi
+	RouteStopped  = "stopped"
+)
+
+// RouteStatus: trạng thái + counters của route cho /routes.
+type RouteStatus struct {
+	Name        string     `json:"name"`
+	From        string     `json:"from"`
+	Targets     []string   `json:"targets"`
+	State       string     `json:"state"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	Stats       RouteStats `json:"stats"`
+}
+
+type routeState struct {
+	name, from string
+	targets    []string
+	counters   *RouteCounters
+
+	mu        sync.Mutex
+	state     string
+	lastErr   string
+	lastErrAt time.Time
+}
+
+func (s *routeState) setState(state string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	if err != nil {
+		s.lastErr, s.lastErrAt = err.Error(), time.Now()
+	}
+}
+
+// stopped: route đang chạy -> stopped (giữ nguyên failed).
+func (s *routeState) stopped() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state != RouteFailed {
+		s.state = RouteStopped
+	}
+}
+
+func (s *routeState) setError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr, s.lastErrAt = err.Error(), time.Now()
+}
+
+func (s *routeState) status() RouteStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := RouteStatus{
+		Name:      s.name,
+		From:      s.from,
+		Targets:   s.targets,
+		State:     s.state,
+		LastError: s.lastErr,
+		Stats:     s.counters.Snapshot(),
+	}
+	if !s.lastErrAt.IsZero() {
+		at := s.lastErrAt
+		st.LastErrorAt = &at
+	}
+	return st
+}
+
+// Routes trả trạng thái mọi route theo thứ tự trong config.
+func (e *Engine) Routes() []RouteStatus {
+	out := make([]RouteStatus, 0, len(e.states))
+	for _, s := range e.states {
+		out = append(out, s.status())
+	}
+	return out
+}
+
+// Ready: mọi route đã subscribe nguồn; trả lý do nếu chưa.
+func (e *Engine) Ready() (bool, []string) {
+	if e.states == nil {
+		return false, []string{"routes not started"}
+	}
+	var reasons []string
+	for _, s := range e.states {
+		if st := s.status(); st.State != RouteRunning {
+			reasons = append(reasons, fmt.Sprintf("route %s is %s", st.Name, st.State))
+		}
+	}
+	return len(reasons) == 0, reasons
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"errors"
+	"testing"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/core"
+)
+
+func to(target string) *cfg.RouteEndpoint {
+	return &cfg.RouteEndpoint{Connector: "bus", Target: target}
+}
+
+func switchRoute(def *cfg.RouteCase) cfg.Route {
+	return cfg.Route{Name: "r", Mode: cfg.RouteMode{Type: "drop"}, Switch: &cfg.RouteSwitch{
+		Cases: []cfg.RouteCase{
+			{When: `payload.amount > 1000`, To: to("big")},
+			{When: `payload.region == "eu"`, To: to("eu")},
+		},
+		Default: def,
+	}}
+}
+
+func TestSwitchCaseOrder(t *testing.T) {
+	e := &Engine{}
+	bus := startRoutes(t, e, switchRoute(&cfg.RouteCase{To: to("other")}))
+
+	for _, v := range []string{
+		`{"amount":5000,"region":"eu"}`, // khớp cả hai case: case đầu thắng
+		`{"amount":10,"region":"eu"}`,
+		`{"amount":10,"region":"us"}`, // không case nào -> default
+		`{"region":"us"}`,             // thiếu amount: case coi như false
+	} {
+		if err := bus.deliver(t, "in", v, nil); err != nil {
+			t.Fatalf("deliver %s: %v", v, err)
+		}
+	}
+	for target, want := range map[string]int{"big": 1, "eu": 1, "other": 2} {
+		if n := len(bus.sent(target)); n != want {
+			t.Errorf("%s received %d, want %d", target, n, want)
+		}
+	}
+}
+
+// không case nào khớp và không có default: message bị lọc (ack), không publish.
+func TestSwitchNoMatchWithoutDefault(t *testing.T) {
+	e := &Engine{}
+	bus := startRoutes(t, e, switchRoute(nil))
+
+	err := bus.deliver(t, "in", `{"amount":10,"region":"us"}`, nil)
+	if err == nil || !errors.Is(err, core.ErrFiltered) {
+		t.Fatalf("err = %v, want ErrFiltered (acked)", err)
+	}
+	for _, target := range []string{"big", "eu", "other"} {
+		if n := len(bus.sent(target)); n != 0 {
+			t.Errorf("%s received %d, want 0", target, n)
+		}
+	}
+	if st := e.Stats()["r"]; st.Filtered != 1 || st.Failed != 0 {
+		t.Fatalf("stats = %+v", st)
+	}
+}
+
+// lỗi đánh giá không phải thiếu trường: message không được ack.
+func TestSwitchEvalError(t *testing.T) {
+	e := &Engine{}
+	r := switchRoute(nil)
+	r.Switch.Cases[0].When = `payload.amount / 0 > 1`
+	bus := startRoutes(t, e, r)
+	if err := bus.deliver(t, "in", `{"amount":1}`, nil); err == nil || errors.Is(err, core.ErrFiltered) {
+		t.Fatalf("err = %v, want a switch failure", err)
+	}
+}
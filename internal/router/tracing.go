@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/cuongceg/validate_yaml/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer global: không cấu hình tracing thì là no-op.
+var tracer = otel.Tracer(tracing.ScopeName + "/router")
+
+// endSpan ghi lỗi (nếu có) rồi đóng span.
+func endSpan(sp trace.Span, err error, attrs ...attribute.KeyValue) {
+	sp.SetAttributes(attrs...)
+	if err != nil {
+		sp.RecordError(err)
+		sp.SetStatus(codes.Error, err.Error())
+	}
+	sp.End()
+}
@@ -0,0 +1,258 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/internal/expr"
+)
+
+// TransformFn biến đổi payload/meta; trả payload mới (có thể chính obj đã sửa tại chỗ).
+type TransformFn func(ctx context.Context, msg *Message, obj map[string]any) (map[string]any, error)
+
+// opFn là một bước đã compile của transform.
+type opFn func(obj, meta map[string]any) error
+
+// CompileTransforms dựng TransformFn theo tên từ transforms[].ops, chạy tuần tự.
+func CompileTransforms(rules []cfg.TransformRule) (map[string]TransformFn, error) {
+	out := make(map[string]TransformFn, len(rules))
+	for _, r := range rules {
+		ops := make([]opFn, 0, len(r.Ops))
+		for i, op := range r.Ops {
+			fn, err := compileOp(op)
+			if err != nil {
+				return nil, fmt.Errorf("transform %q: ops[%d] (%s): %w", r.Name, i, op.Op, err)
+			}
+			ops = append(ops, fn)
+		}
+		name := r.Name
+		out[r.Name] = func(ctx context.Context, msg *Message, obj map[string]any) (map[string]any, error) {
+			if obj == nil {
+				obj = map[string]any{}
+			}
+			if msg.Meta == nil {
+				msg.Meta = map[string]any{}
+			}
+			for i, op := range ops {
+				if err := op(obj, msg.Meta); err != nil {
+					return nil, fmt.Errorf("transform %q: ops[%d]: %w", name, i, err)
+				}
+			}
+			return obj, nil
+		}
+	}
+	return out, nil
+}
+
+// fieldRef là path đã tách: root payload|meta.
+type fieldRef struct {
+	meta bool
+	segs []string
+}
+
+func parseField(p string) (fieldRef, error) {
+	root, segs, err := expr.SplitPath(p)
+	if err != nil {
+		return fieldRef{}, err
+	}
+	return fieldRef{meta: root == "meta", segs: segs}, nil
+}
+
+func (f fieldRef) target(obj, meta map[string]any) map[string]any {
+	if f.meta {
+		return meta
+	}
+	return obj
+}
+
+func (f fieldRef) get(obj, meta map[string]any) (any, bool) {
+	return expr.Lookup(f.target(obj, meta), f.segs)
+}
+
+func (f fieldRef) set(obj, meta map[string]any, v any) {
+	expr.Set(f.target(obj, meta), f.segs, v)
+}
+
+func (f fieldRef) update(obj, meta map[string]any, fn func(any) (any, error)) error {
+	return expr.Update(f.target(obj, meta), f.segs, fn)
+}
+
+func compileOp(op cfg.TransformOp) (opFn, error) {
+	field, err := parseField(op.Field)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(op.Op) {
+	case "rename", "copy":
+		to, err := parseField(op.To)
+		if err != nil {
+			return nil, err
+		}
+		// Lookup không duyệt mảng: x[] ở đây sẽ không làm gì mà không báo lỗi
+		if strings.Contains(op.Field, "[]") || strings.Contains(op.To, "[]") {
+			return nil, fmt.Errorf("array paths ([]) are not supported")
+		}
+		move := strings.EqualFold(op.Op, "rename")
+		return func(obj, meta map[string]any) error {
+			v, ok := field.get(obj, meta)
+			if !ok {
+				return nil
+			}
+			if move {
+				expr.Delete(field.target(obj, meta), field.segs)
+			}
+			to.set(obj, meta, v)
+			return nil
+		}, nil
+	case "set":
+		if op.Expr == "" {
+			v := op.Value
+			return func(obj, meta map[string]any) error {
+				field.set(obj, meta, v)
+				return nil
+			}, nil
+		}
+		prg, err := expr.CompileValue(op.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return func(obj, meta map[string]any) error {
+			v, err := prg.Value(obj, meta)
+			if err != nil {
+				if expr.IsMissingField(err) {
+					return nil
+				}
+				return err
+			}
+			field.set(obj, meta, v)
+			return nil
+		}, nil
+	case "delete":
+		return func(obj, meta map[string]any) error {
+			expr.Delete(field.target(obj, meta), field.segs)
+			return nil
+		}, nil
+	case "mask":
+		char := op.MaskChar
+		if char == "" {
+			char = "*"
+		}
+		keep := op.KeepLast
+		return func(obj, meta map[string]any) error {
+			return field.update(obj, meta, eachScalar(func(v any) (any, error) {
+				return maskString(toString(v), keep, char), nil
+			}))
+		}, nil
+	case "hash":
+		salt := op.Salt
+		return func(obj, meta map[string]any) error {
+			return field.update(obj, meta, eachScalar(func(v any) (any, error) {
+				sum := sha256.Sum256([]byte(salt + toString(v)))
+				return hex.EncodeToString(sum[:]), nil
+			}))
+		}, nil
+	case "convert":
+		kind := strings.ToLower(op.Type)
+		return func(obj, meta map[string]any) error {
+			return field.update(obj, meta, eachScalar(func(v any) (any, error) {
+				return convertValue(v, kind)
+			}))
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// eachScalar áp fn cho giá trị đơn hoặc từng phần tử của mảng (vd: phones: [..]).
+func eachScalar(fn func(any) (any, error)) func(any) (any, error) {
+	return func(v any) (any, error) {
+		if v == nil {
+			return nil, nil
+		}
+		items, ok := v.([]any)
+		if !ok {
+			return fn(v)
+		}
+		out := make([]any, len(items))
+		for i, it := range items {
+			nv, err := fn(it)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = nv
+		}
+		return out, nil
+	}
+}
+
+// maskString giữ keep ký tự cuối, còn lại thay bằng char (0987654321 -> ******4321).
+func maskString(s string, keep int, char string) string {
+	r := []rune(s)
+	if keep >= len(r) {
+		return s
+	}
+	return strings.Repeat(char, len(r)-keep) + string(r[len(r)-keep:])
+}
+
+func toString(v any) string {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	case []byte:
+		return string(vv)
+	}
+	return fmt.Sprint(v)
+}
+
+func convertValue(v any, kind string) (any, error) {
+	switch kind {
+	case "string":
+		return toString(v), nil
+	case "int":
+		if n, ok := toInt64(v); ok {
+			return n, nil
+		}
+		if f, ok := v.(float64); ok {
+			return int64(f), nil
+		}
+		if b, ok := v.(bool); ok {
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(toString(v)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("convert %v to int: %w", v, err)
+		}
+		return n, nil
+	case "float":
+		if f, ok := toFloat64(v); ok {
+			return f, nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(toString(v)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("convert %v to float: %w", v, err)
+		}
+		return f, nil
+	case "bool":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		if n, ok := toInt64(v); ok {
+			return n != 0, nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(toString(v)))
+		if err != nil {
+			return nil, fmt.Errorf("convert %v to bool: %w", v, err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", kind)
+}
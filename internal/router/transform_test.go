@@ -0,0 +1,140 @@
+package router
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	cfg "github.com/cuongceg/validate_yaml/internal/config"
+	"github.com/cuongceg/validate_yaml/proto/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMaskString(t *testing.T) {
+	tests := []struct {
+		in   string
+		keep int
+		char string
+		want string
+	}{
+		{"0987654321", 4, "*", "******4321"},
+		{"0987654321", 0, "*", "**********"},
+		{"4321", 4, "*", "4321"},
+		{"321", 10, "*", "321"},
+		{"", 2, "*", ""},
+		{"Nguyễn Văn", 3, "#", "#######Văn"},
+		{"电话号码", 1, "x", "xxx码"},
+	}
+	for _, tt := range tests {
+		if got := maskString(tt.in, tt.keep, tt.char); got != tt.want {
+			t.Errorf("maskString(%q, %d) = %q, want %q", tt.in, tt.keep, got, tt.want)
+		}
+	}
+}
+
+func runTransform(t *testing.T, ops []cfg.TransformOp, obj, meta map[string]any) map[string]any {
+	t.Helper()
+	fns, err := CompileTransforms([]cfg.TransformRule{{Name: "t", Ops: ops}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := fns["t"](context.Background(), &Message{Meta: meta}, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// ops chạy đúng thứ tự khai báo: op sau thấy kết quả của op trước.
+func TestTransformOpOrder(t *testing.T) {
+	meta := map[string]any{"tenant": "acme"}
+	got := runTransform(t, []cfg.TransformOp{
+		{Op: "rename", Field: "payload.tel", To: "payload.contact.phone"},
+		{Op: "set", Field: "payload.contact.backup", Expr: `payload.contact.phone`},
+		{Op: "set", Field: "payload.tenant", Expr: `meta.tenant`},
+		{Op: "delete", Field: "payload.secret"},
+		{Op: "mask", Field: "payload.contact.phone", KeepLast: 2},
+		{Op: "hash", Field: "payload.tenant", Salt: "s"},
+	}, map[string]any{"tel": "0987654321", "secret": "x"}, meta)
+
+	contact := got["contact"].(map[string]any)
+	if contact["phone"] != "********21" {
+		t.Errorf("phone = %v, want masked after rename", contact["phone"])
+	}
+	if contact["backup"] != "0987654321" {
+		t.Errorf("backup = %v, set must see the renamed value before mask", contact["backup"])
+	}
+	if _, ok := got["tel"]; ok {
+		t.Error("rename must remove the source field")
+	}
+	if _, ok := got["secret"]; ok {
+		t.Error("secret not deleted")
+	}
+	if h, _ := got["tenant"].(string); len(h) != 64 || h == "acme" {
+		t.Errorf("tenant = %v, want sha256 hex", got["tenant"])
+	}
+}
+
+func TestTransformMaskArray(t *testing.T) {
+	got := runTransform(t, []cfg.TransformOp{
+		{Op: "mask", Field: "payload.phones", KeepLast: 3, MaskChar: "x"},
+		{Op: "convert", Field: "payload.items[].qty", Type: "int"},
+	}, map[string]any{
+		"phones": []any{"0901234567", "0912345678"},
+		"items":  []any{map[string]any{"qty": "2"}, map[string]any{"qty": 3.0}},
+	}, nil)
+	if want := []any{"xxxxxxx567", "xxxxxxx678"}; !reflect.DeepEqual(got["phones"], want) {
+		t.Errorf("phones = %v, want %v", got["phones"], want)
+	}
+	items := got["items"].([]any)
+	if items[0].(map[string]any)["qty"] != int64(2) || items[1].(map[string]any)["qty"] != int64(3) {
+		t.Errorf("items = %v", items)
+	}
+}
+
+func TestTransformRejectsArrayRename(t *testing.T) {
+	for _, op := range []cfg.TransformOp{
+		{Op: "rename", Field: "payload.items[].sku", To: "payload.items[].code"},
+		{Op: "copy", Field: "payload.sku", To: "payload.items[].sku"},
+	} {
+		_, err := CompileTransforms([]cfg.TransformRule{{Name: "t", Ops: []cfg.TransformOp{op}}})
+		if err == nil || !strings.Contains(err.Error(), "array paths") {
+			t.Errorf("%s %s -> %s: err = %v, want array path error", op.Op, op.Field, op.To, err)
+		}
+	}
+}
+
+// Envelope protobuf: phone bị mask trước khi encode, bytes publish không còn số gốc.
+func TestTransformMasksEnvelopeBeforeEncode(t *testing.T) {
+	fns, err := CompileTransforms([]cfg.TransformRule{{Name: "pii", Ops: []cfg.TransformOp{
+		{Op: "mask", Field: "payload.phone", KeepLast: 4},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Engine{DefaultCodec: NewProtoCodec[*pb.Envelope](), Transforms: fns}
+	bus := startRoutes(t, e, cfg.Route{Name: "r", Mode: cfg.RouteMode{Type: "drop"}, Transform: "pii"})
+
+	in, err := proto.Marshal(&pb.Envelope{Name: "An", Age: 30, Phone: "0987654321"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.deliver(t, "in", string(in), nil); err != nil {
+		t.Fatal(err)
+	}
+	sent := bus.sent("out")
+	if len(sent) != 1 {
+		t.Fatalf("published %d, want 1", len(sent))
+	}
+	if strings.Contains(string(sent[0].Value), "0987654321") {
+		t.Fatal("published bytes still contain the original phone")
+	}
+	var out pb.Envelope
+	if err := proto.Unmarshal(sent[0].Value, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Phone != "******4321" || out.Name != "An" || out.Age != 30 {
+		t.Fatalf("published envelope = %v", &out)
+	}
+}
@@ -0,0 +1,92 @@
+package schemaregistry
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// cached giữ kết quả bất biến (schema theo ID, subject/version cụ thể, ID sau
+// register/lookup) để mỗi message không phải gọi HTTP.
+type cached struct {
+	next Client
+
+	mu       sync.RWMutex
+	byID     map[int]*Schema
+	versions map[string]*Schema
+	ids      map[string]int
+}
+
+func NewCached(next Client) Client {
+	return &cached{
+		next:     next,
+		byID:     map[int]*Schema{},
+		versions: map[string]*Schema{},
+		ids:      map[string]int{},
+	}
+}
+
+func (c *cached) SchemaByID(ctx context.Context, id int) (*Schema, error) {
+	c.mu.RLock()
+	s, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+	s, err := c.next.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.byID[id] = s
+	c.mu.Unlock()
+	return s, nil
+}
+
+func (c *cached) Version(ctx context.Context, subject string, version int) (*Schema, error) {
+	// latest có thể đổi -> không cache
+	if version <= 0 {
+		return c.next.Version(ctx, subject, version)
+	}
+	key := subject + "/" + strconv.Itoa(version)
+	c.mu.RLock()
+	s, ok := c.versions[key]
+	c.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+	s, err := c.next.Version(ctx, subject, version)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.versions[key] = s
+	c.mu.Unlock()
+	return s, nil
+}
+
+func (c *cached) Register(ctx context.Context, subject string, s Schema) (int, error) {
+	return c.id(subject, s, func() (int, error) { return c.next.Register(ctx, subject, s) })
+}
+
+func (c *cached) Lookup(ctx context.Context, subject string, s Schema) (int, error) {
+	return c.id(subject, s, func() (int, error) { return c.next.Lookup(ctx, subject, s) })
+}
+
+func (c *cached) id(subject string, s Schema, fetch func() (int, error)) (int, error) {
+	key := subject + "\x00" + s.SchemaType() + "\x00" + s.Schema
+	c.mu.RLock()
+	id, ok := c.ids[key]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+	id, err := fetch()
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.ids[key] = id
+	c.mu.Unlock()
+	return id, nil
+}
@@ -0,0 +1,111 @@
+// Package schemaregistry: client Confluent Schema Registry (HTTP, cache, file offline)
+// và wire format (magic byte + schema ID) dùng chung cho Avro/Protobuf/JSON Schema.
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	TypeAvro     = "AVRO"
+	TypeProtobuf = "PROTOBUF"
+	TypeJSON     = "JSON"
+)
+
+// ErrNotFound: schema/subject/version không tồn tại trên registry.
+var ErrNotFound = errors.New("schema registry: not found")
+
+type Reference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+type Schema struct {
+	ID         int         `json:"id,omitempty"`
+	Subject    string      `json:"subject,omitempty"`
+	Version    int         `json:"version,omitempty"`
+	Type       string      `json:"schemaType,omitempty"` // "" = AVRO (theo API Confluent)
+	Schema     string      `json:"schema"`
+	References []Reference `json:"references,omitempty"`
+}
+
+// SchemaType trả type đã chuẩn hóa (mặc định AVRO).
+func (s *Schema) SchemaType() string {
+	if s.Type == "" {
+		return TypeAvro
+	}
+	return strings.ToUpper(s.Type)
+}
+
+type Client interface {
+	SchemaByID(ctx context.Context, id int) (*Schema, error)
+	// Version trả schema của subject theo version; version <= 0 = latest.
+	Version(ctx context.Context, subject string, version int) (*Schema, error)
+	// Register đăng ký (idempotent) schema dưới subject, trả schema ID.
+	Register(ctx context.Context, subject string, s Schema) (int, error)
+	// Lookup tìm ID của schema đã đăng ký dưới subject (ErrNotFound nếu chưa có).
+	Lookup(ctx context.Context, subject string, s Schema) (int, error)
+}
+
+// ====== Wire format ======
+
+const magicByte = 0
+
+// AppendHeader ghi magic byte + schema ID (big-endian 4 byte).
+func AppendHeader(b []byte, id int) []byte {
+	b = append(b, magicByte)
+	return binary.BigEndian.AppendUint32(b, uint32(id))
+}
+
+// ParseHeader tách schema ID và phần payload còn lại.
+func ParseHeader(b []byte) (id int, rest []byte, err error) {
+	if len(b) < 5 {
+		return 0, nil, fmt.Errorf("schema registry: payload too short (%d bytes)", len(b))
+	}
+	if b[0] != magicByte {
+		return 0, nil, fmt.Errorf("schema registry: unknown magic byte %d", b[0])
+	}
+	return int(binary.BigEndian.Uint32(b[1:5])), b[5:], nil
+}
+
+// AppendMessageIndexes ghi đường dẫn message protobuf (zigzag varint: số phần tử
+// rồi từng index); [0] (message đầu tiên) rút gọn thành một byte 0.
+func AppendMessageIndexes(b []byte, idx []int) []byte {
+	if len(idx) == 1 && idx[0] == 0 {
+		return append(b, 0)
+	}
+	b = binary.AppendVarint(b, int64(len(idx)))
+	for _, i := range idx {
+		b = binary.AppendVarint(b, int64(i))
+	}
+	return b
+}
+
+// ReadMessageIndexes đọc đường dẫn message protobuf, trả số byte đã đọc.
+func ReadMessageIndexes(b []byte) ([]int, int, error) {
+	n, off := binary.Varint(b)
+	if off <= 0 {
+		return nil, 0, errors.New("schema registry: invalid message indexes")
+	}
+	if n == 0 {
+		return []int{0}, off, nil
+	}
+	if n < 0 || n > int64(len(b)) {
+		return nil, 0, fmt.Errorf("schema registry: invalid message index count %d", n)
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		v, k := binary.Varint(b[off:])
+		if k <= 0 {
+			return nil, 0, errors.New("schema registry: invalid message indexes")
+		}
+		idx[i] = int(v)
+		off += k
+	}
+	return idx, off, nil
+}
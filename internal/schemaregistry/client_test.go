@@ -0,0 +1,48 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestHeaderRoundTrip(t *testing.T) {
+	b := AppendHeader(nil, 42)
+	if want := []byte{0, 0, 0, 0, 42}; !bytes.Equal(b, want) {
+		t.Fatalf("AppendHeader = %v, want %v", b, want)
+	}
+	id, rest, err := ParseHeader(append(b, "payload"...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 42 || string(rest) != "payload" {
+		t.Fatalf("ParseHeader = %d %q", id, rest)
+	}
+}
+
+func TestParseHeaderErrors(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"short": {0, 0, 1},
+		"magic": {1, 0, 0, 0, 1, 'x'},
+	} {
+		if _, _, err := ParseHeader(b); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestMessageIndexesRoundTrip(t *testing.T) {
+	for _, idx := range [][]int{{0}, {1}, {2, 0, 3}} {
+		b := AppendMessageIndexes(nil, idx)
+		if len(idx) == 1 && idx[0] == 0 && !bytes.Equal(b, []byte{0}) {
+			t.Errorf("[0] should be encoded as a single 0 byte, got %v", b)
+		}
+		got, n, err := ReadMessageIndexes(append(b, 0xff))
+		if err != nil {
+			t.Fatalf("%v: %v", idx, err)
+		}
+		if n != len(b) || !reflect.DeepEqual(got, idx) {
+			t.Errorf("%v: got %v (read %d of %d bytes)", idx, got, n, len(b))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+type HTTPConfig struct {
+	URL      string
+	Username string // basic auth (tùy chọn)
+	Password string
+	Timeout  time.Duration
+}
+
+// httpClient gọi REST API của Confluent Schema Registry.
+type httpClient struct {
+	base string
+	cfg  HTTPConfig
+	hc   *http.Client
+}
+
+func NewHTTPClient(cfg HTTPConfig) (Client, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("schema registry: invalid url %q", cfg.URL)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &httpClient{
+		base: strings.TrimRight(cfg.URL, "/"),
+		cfg:  cfg,
+		hc:   &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// lỗi trả về từ registry: {"error_code":40403,"message":"Schema not found"}
+type apiError struct {
+	Code    int    `json:"error_code"`
+	Message string `json:"message"`
+}
+
+func (c *httpClient) do(ctx context.Context, method, path string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("schema registry: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var ae apiError
+		_ = json.NewDecoder(resp.Body).Decode(&ae)
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s %s: %s", ErrNotFound, method, path, ae.Message)
+		}
+		return fmt.Errorf("schema registry: %s %s: status %d (code %d): %s", method, path, resp.StatusCode, ae.Code, ae.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("schema registry: %s %s: decode response: %w", method, path, err)
+	}
+	return nil
+}
+
+func (c *httpClient) SchemaByID(ctx context.Context, id int) (*Schema, error) {
+	var s Schema
+	if err := c.do(ctx, http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &s); err != nil {
+		return nil, err
+	}
+	s.ID = id
+	return &s, nil
+}
+
+func (c *httpClient) Version(ctx context.Context, subject string, version int) (*Schema, error) {
+	v := "latest"
+	if version > 0 {
+		v = strconv.Itoa(version)
+	}
+	var s Schema
+	if err := c.do(ctx, http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions/"+v, nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (c *httpClient) Register(ctx context.Context, subject string, s Schema) (int, error) {
+	var out struct {
+		ID int `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", requestBody(s), &out); err != nil {
+		return 0, err
+	}
+	return out.ID, nil
+}
+
+func (c *httpClient) Lookup(ctx context.Context, subject string, s Schema) (int, error) {
+	var out Schema
+	if err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject), requestBody(s), &out); err != nil {
+		return 0, err
+	}
+	return out.ID, nil
+}
+
+// requestBody: body cho register/lookup (AVRO thì bỏ schemaType như Confluent).
+func requestBody(s Schema) Schema {
+	body := Schema{Schema: s.Schema, References: s.References}
+	if t := s.SchemaType(); t != TypeAvro {
+		body.Type = t
+	}
+	return body
+}
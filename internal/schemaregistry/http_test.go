@@ -0,0 +1,135 @@
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+const orderAvro = `{"type":"record","name":"Order","fields":[{"name":"id","type":"string"}]}`
+
+// newFake chạy Memory làm registry giả qua HTTP; calls đếm số request tới registry.
+func newFake(t *testing.T) (Client, *Memory, *atomic.Int32) {
+	t.Helper()
+	mem := NewMemory()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		mem.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := NewHTTPClient(HTTPConfig{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, mem, &calls
+}
+
+func TestHTTPClientAgainstFakeRegistry(t *testing.T) {
+	ctx := context.Background()
+	c, _, _ := newFake(t)
+
+	id, err := c.Register(ctx, "orders-value", Schema{Schema: orderAvro})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.Register(ctx, "orders-value", Schema{Schema: orderAvro})
+	if err != nil || again != id {
+		t.Fatalf("register again = %d, %v; want %d (idempotent)", again, err, id)
+	}
+
+	s, err := c.SchemaByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != id || s.SchemaType() != TypeAvro || s.Schema != orderAvro {
+		t.Fatalf("SchemaByID = %+v", s)
+	}
+
+	latest, err := c.Version(ctx, "orders-value", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ID != id || latest.Version != 1 {
+		t.Fatalf("latest = %+v", latest)
+	}
+
+	got, err := c.Lookup(ctx, "orders-value", Schema{Schema: orderAvro})
+	if err != nil || got != id {
+		t.Fatalf("Lookup = %d, %v; want %d", got, err, id)
+	}
+}
+
+func TestHTTPClientNotFound(t *testing.T) {
+	ctx := context.Background()
+	c, _, _ := newFake(t)
+
+	if _, err := c.SchemaByID(ctx, 999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SchemaByID(unknown) = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Version(ctx, "missing-value", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Version(unknown subject) = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Lookup(ctx, "orders-value", Schema{Schema: orderAvro}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup(unregistered) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCachedSkipsRegistry(t *testing.T) {
+	ctx := context.Background()
+	hc, mem, calls := newFake(t)
+	id, err := mem.Register(ctx, "orders-value", Schema{Schema: orderAvro})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCached(hc)
+	for i := 0; i < 3; i++ {
+		if _, err := c.SchemaByID(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Lookup(ctx, "orders-value", Schema{Schema: orderAvro}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("registry calls = %d, want 2 (one per distinct lookup)", n)
+	}
+	// lỗi không được cache
+	if _, err := c.SchemaByID(ctx, 999); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("SchemaByID(unknown) = %v", err)
+	}
+	if _, err := c.SchemaByID(ctx, 999); err == nil || calls.Load() != 4 {
+		t.Fatalf("unknown id should hit the registry again (calls = %d)", calls.Load())
+	}
+}
+
+func TestFileRegistryPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "registry.json")
+	m, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := m.Register(ctx, "orders-value", Schema{Schema: orderAvro})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := reopened.SchemaByID(ctx, id)
+	if err != nil || s.Schema != orderAvro {
+		t.Fatalf("reopened SchemaByID = %+v, %v", s, err)
+	}
+	next, err := reopened.Register(ctx, "other-value", Schema{Type: TypeJSON, Schema: `{"type":"object"}`})
+	if err != nil || next == id {
+		t.Fatalf("new schema id = %d, %v; want a fresh id", next, err)
+	}
+}
@@ -0,0 +1,238 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Memory là registry trong process: dùng làm chế độ offline (file JSON) và làm
+// registry giả cho test (ServeHTTP phục vụ cùng API với Confluent, chạy qua httptest).
+type Memory struct {
+	mu      sync.Mutex
+	path    string // != "" -> Register ghi lại file
+	schemas []Schema
+	nextID  int
+
+	muxOnce sync.Once
+	mux     *http.ServeMux
+}
+
+// memoryFile là định dạng file offline: {"schemas":[{"id":1,"subject":"orders-value","version":1,"schemaType":"AVRO","schema":"..."}]}
+type memoryFile struct {
+	Schemas []Schema `json:"schemas"`
+}
+
+func NewMemory() *Memory { return &Memory{nextID: 1} }
+
+// OpenFile nạp registry từ file; file chưa tồn tại được coi là rỗng.
+func OpenFile(path string) (*Memory, error) {
+	m := NewMemory()
+	m.path = path
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f memoryFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("schema registry file %s: %w", path, err)
+	}
+	for i, s := range f.Schemas {
+		if s.ID <= 0 || s.Subject == "" || strings.TrimSpace(s.Schema) == "" {
+			return nil, fmt.Errorf("schema registry file %s: schemas[%d]: id, subject and schema are required", path, i)
+		}
+		if s.Version <= 0 {
+			s.Version = m.latestVersion(s.Subject) + 1
+		}
+		m.schemas = append(m.schemas, s)
+		if s.ID >= m.nextID {
+			m.nextID = s.ID + 1
+		}
+	}
+	return m, nil
+}
+
+func sameSchema(a, b Schema) bool {
+	return a.SchemaType() == b.SchemaType() && strings.TrimSpace(a.Schema) == strings.TrimSpace(b.Schema)
+}
+
+func (m *Memory) latestVersion(subject string) int {
+	v := 0
+	for _, s := range m.schemas {
+		if s.Subject == subject && s.Version > v {
+			v = s.Version
+		}
+	}
+	return v
+}
+
+func (m *Memory) SchemaByID(_ context.Context, id int) (*Schema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.schemas {
+		if s.ID == id {
+			return &Schema{ID: id, Type: s.Type, Schema: s.Schema, References: s.References}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: schema id %d", ErrNotFound, id)
+}
+
+func (m *Memory) Version(_ context.Context, subject string, version int) (*Schema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if version <= 0 {
+		version = m.latestVersion(subject)
+	}
+	for _, s := range m.schemas {
+		if s.Subject == subject && s.Version == version {
+			out := s
+			return &out, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: subject %q version %d", ErrNotFound, subject, version)
+}
+
+func (m *Memory) Register(_ context.Context, subject string, s Schema) (int, error) {
+	if strings.TrimSpace(s.Schema) == "" {
+		return 0, errors.New("schema registry: empty schema")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := 0
+	for _, old := range m.schemas {
+		if !sameSchema(old, s) {
+			continue
+		}
+		if old.Subject == subject {
+			return old.ID, nil
+		}
+		id = old.ID // cùng nội dung ở subject khác -> dùng lại ID
+	}
+	if id == 0 {
+		id = m.nextID
+		m.nextID++
+	}
+	m.schemas = append(m.schemas, Schema{
+		ID:         id,
+		Subject:    subject,
+		Version:    m.latestVersion(subject) + 1,
+		Type:       s.SchemaType(),
+		Schema:     s.Schema,
+		References: s.References,
+	})
+	if err := m.saveLocked(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (m *Memory) Lookup(_ context.Context, subject string, s Schema) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, old := range m.schemas {
+		if old.Subject == subject && sameSchema(old, s) {
+			return old.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: schema under subject %q", ErrNotFound, subject)
+}
+
+func (m *Memory) saveLocked() error {
+	if m.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(memoryFile{Schemas: m.schemas}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, b, 0o644)
+}
+
+// ====== HTTP (registry giả) ======
+
+func (m *Memory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.muxOnce.Do(m.buildMux)
+	m.mux.ServeHTTP(w, r)
+}
+
+func (m *Memory) buildMux() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /schemas/ids/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, 40403, "Schema not found")
+			return
+		}
+		s, err := m.SchemaByID(r.Context(), id)
+		if err != nil {
+			writeError(w, http.StatusNotFound, 40403, "Schema not found")
+			return
+		}
+		writeJSON(w, s)
+	})
+	mux.HandleFunc("GET /subjects/{subject}/versions/{version}", func(w http.ResponseWriter, r *http.Request) {
+		v := 0
+		if vs := r.PathValue("version"); vs != "latest" {
+			n, err := strconv.Atoi(vs)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusUnprocessableEntity, 42202, "Invalid version")
+				return
+			}
+			v = n
+		}
+		s, err := m.Version(r.Context(), r.PathValue("subject"), v)
+		if err != nil {
+			writeError(w, http.StatusNotFound, 40402, "Version not found")
+			return
+		}
+		writeJSON(w, s)
+	})
+	mux.HandleFunc("POST /subjects/{subject}/versions", func(w http.ResponseWriter, r *http.Request) {
+		var s Schema
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil || strings.TrimSpace(s.Schema) == "" {
+			writeError(w, http.StatusUnprocessableEntity, 42201, "Invalid schema")
+			return
+		}
+		id, err := m.Register(r.Context(), r.PathValue("subject"), s)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, 50001, err.Error())
+			return
+		}
+		writeJSON(w, map[string]int{"id": id})
+	})
+	mux.HandleFunc("POST /subjects/{subject}", func(w http.ResponseWriter, r *http.Request) {
+		var s Schema
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, 42201, "Invalid schema")
+			return
+		}
+		subject := r.PathValue("subject")
+		id, err := m.Lookup(r.Context(), subject, s)
+		if err != nil {
+			writeError(w, http.StatusNotFound, 40403, "Schema not found")
+			return
+		}
+		writeJSON(w, Schema{ID: id, Subject: subject, Type: s.Type, Schema: s.Schema})
+	})
+	m.mux = mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", contentType)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status, code int, msg string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiError{Code: code, Message: msg})
+}
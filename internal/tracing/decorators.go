@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/cuongceg/validate_yaml/internal/core"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ingress: extract traceparent từ header rồi mở span "receive" (consumer) cho handler.
+type ingress struct {
+	core.Ingress
+	system, connector string
+}
+
+func (d ingress) Start(ctx context.Context, h core.Handler) error {
+	tracer := otel.Tracer(ScopeName)
+	src := d.SourceName()
+	return d.Ingress.Start(ctx, func(ctx context.Context, msg []byte, meta map[string]string) error {
+		ctx, span := tracer.Start(Extract(ctx, meta), "receive "+src,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(messagingAttrs(d.system, d.connector, src)...))
+		defer span.End()
+		err := h(ctx, msg, meta)
+		if !core.Acked(err) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	})
+}
+
+// Lag chuyển tiếp tới ingress gốc (metrics đọc consumer lag).
+func (d ingress) Lag() int64 {
+	if lr, ok := d.Ingress.(core.LagReporter); ok {
+		return lr.Lag()
+	}
+	return -1
+}
+
+// egress: mở span "send" (producer) và inject context của span vào header gửi đi.
+type egress struct {
+	core.Egress
+	system, connector string
+}
+
+func (d egress) Publish(ctx context.Context, msg []byte, meta map[string]string) error {
+	target := d.TargetName()
+	ctx, span := otel.Tracer(ScopeName).Start(ctx, "send "+target,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messagingAttrs(d.system, d.connector, target)...))
+	defer span.End()
+
+	out := make(map[string]string, len(meta)+2)
+	for k, v := range meta {
+		out[k] = v
+	}
+	Inject(ctx, out)
+	err := d.Egress.Publish(ctx, msg, out)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+type connector struct {
+	core.Connector
+	ing []core.Ingress
+	eg  []core.Egress
+}
+
+func (c *connector) Ingresses() []core.Ingress { return c.ing }
+func (c *connector) Egresses() []core.Egress   { return c.eg }
+func (c *connector) Unwrap() core.Connector    { return c.Connector }
+
+// Wrap bọc ingress/egress của connector; system là loại broker (kafka|nats|rabbitmq).
+func Wrap(c core.Connector, system string) core.Connector {
+	tc := &connector{Connector: c}
+	for _, in := range c.Ingresses() {
+		tc.ing = append(tc.ing, ingress{Ingress: in, system: system, connector: c.Name()})
+	}
+	for _, eg := range c.Egresses() {
+		tc.eg = append(tc.eg, egress{Egress: eg, system: system, connector: c.Name()})
+	}
+	return tc
+}
@@ -0,0 +1,83 @@
+// Package tracing cấu hình OpenTelemetry cho bridge và bọc ingress/egress để
+// truyền W3C trace context (traceparent/tracestate) qua header Kafka/AMQP/NATS.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const ScopeName = "github.com/cuongceg/validate_yaml"
+
+type Config struct {
+	Endpoint    string // host:port OTLP/HTTP (vd localhost:4318); rỗng -> biến môi trường OTEL_EXPORTER_OTLP_*
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64 // 0 -> 1 (lấy mọi trace)
+}
+
+// Setup cài TracerProvider export qua OTLP/HTTP và propagator W3C làm global.
+// Trả hàm shutdown để flush span còn lại khi tắt.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exp, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tp := newProvider(cfg, sdktrace.WithBatcher(exp, sdktrace.WithBatchTimeout(2*time.Second)))
+	install(tp)
+	return tp.Shutdown, nil
+}
+
+func newProvider(cfg Config, export sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	name := cfg.ServiceName
+	if name == "" {
+		name = "bridge"
+	}
+	ratio := cfg.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	return sdktrace.NewTracerProvider(
+		export,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(name))),
+	)
+}
+
+func install(tp *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Extract đọc trace context từ meta (header của message) vào ctx.
+func Extract(ctx context.Context, meta map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(meta))
+}
+
+// Inject ghi trace context của ctx vào meta (egress publish meta thành header).
+func Inject(ctx context.Context, meta map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(meta))
+}
+
+func messagingAttrs(system, connector, dest string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		semconv.MessagingSystemKey.String(system),
+		semconv.MessagingDestinationName(dest),
+		attribute.String("bridge.connector", connector),
+	}
+}
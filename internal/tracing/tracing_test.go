@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cuongceg/validate_yaml/internal/core"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupInMemory: span được giữ trong bộ nhớ (exp.GetSpans()) thay vì export OTLP.
+func setupInMemory(t *testing.T) *tracetest.InMemoryExporter {
+	exp := tracetest.NewInMemoryExporter()
+	tp := newProvider(Config{ServiceName: "test"}, sdktrace.WithSyncer(exp))
+	install(tp)
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	return exp
+}
+
+// fakeConnector: ingress gọi handler khi test deliver, egress ghi lại meta được publish.
+type fakeConnector struct {
+	in  *fakeIngress
+	out *fakeEgress
+}
+
+func (c *fakeConnector) Name() string              { return "k" }
+func (c *fakeConnector) Open() error               { return nil }
+func (c *fakeConnector) Close() error              { return nil }
+func (c *fakeConnector) Ingresses() []core.Ingress { return []core.Ingress{c.in} }
+func (c *fakeConnector) Egresses() []core.Egress   { return []core.Egress{c.out} }
+
+type fakeIngress struct{ h core.Handler }
+
+func (i *fakeIngress) SourceName() string                            { return "orders" }
+func (i *fakeIngress) Start(_ context.Context, h core.Handler) error { i.h = h; return nil }
+func (i *fakeIngress) Stop(context.Context) error                    { return nil }
+
+type fakeEgress struct{ meta map[string]string }
+
+func (e *fakeEgress) TargetName() string { return "out" }
+func (e *fakeEgress) Close() error       { return nil }
+func (e *fakeEgress) Publish(_ context.Context, _ []byte, meta map[string]string) error {
+	e.meta = meta
+	return nil
+}
+
+func TestIngressToEgressPropagation(t *testing.T) {
+	exp := setupInMemory(t)
+	fc := &fakeConnector{in: &fakeIngress{}, out: &fakeEgress{}}
+	c := Wrap(fc, "kafka")
+
+	// producer phía trước: span "upstream" với traceparent trong header
+	ctx, upstream := otel.Tracer("test").Start(context.Background(), "upstream")
+	in := map[string]string{"content-type": "application/json"}
+	Inject(ctx, in)
+	upstream.End()
+
+	eg := c.Egresses()[0]
+	if err := c.Ingresses()[0].Start(context.Background(), func(ctx context.Context, msg []byte, meta map[string]string) error {
+		return eg.Publish(ctx, msg, meta)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fc.in.h(context.Background(), []byte(`{}`), in); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exp.GetSpans() {
+		spans[s.Name] = s
+	}
+	recv, send := spans["receive orders"], spans["send out"]
+	if recv.Name == "" || send.Name == "" {
+		t.Fatalf("spans = %v", exp.GetSpans().Snapshots())
+	}
+
+	up := upstream.SpanContext()
+	if recv.Parent.SpanID() != up.SpanID() || recv.SpanContext.TraceID() != up.TraceID() {
+		t.Errorf("receive parent = %s, want upstream %s", recv.Parent.SpanID(), up.SpanID())
+	}
+	if recv.SpanKind != trace.SpanKindConsumer || send.SpanKind != trace.SpanKindProducer {
+		t.Errorf("kinds = %s / %s", recv.SpanKind, send.SpanKind)
+	}
+	if send.Parent.SpanID() != recv.SpanContext.SpanID() || send.SpanContext.TraceID() != up.TraceID() {
+		t.Errorf("send parent = %s, want receive %s", send.Parent.SpanID(), recv.SpanContext.SpanID())
+	}
+
+	// header gửi đi mang traceparent của span "send", meta gốc không bị sửa
+	want := "00-" + up.TraceID().String() + "-" + send.SpanContext.SpanID().String() + "-01"
+	if got := fc.out.meta["traceparent"]; got != want {
+		t.Errorf("outgoing traceparent = %q, want %q", got, want)
+	}
+	if fc.out.meta["content-type"] != "application/json" {
+		t.Errorf("outgoing meta = %v", fc.out.meta)
+	}
+	if in["traceparent"] == fc.out.meta["traceparent"] {
+		t.Error("egress must not overwrite the ingress meta")
+	}
+}
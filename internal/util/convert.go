@@ -3,6 +3,7 @@ package util
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	config "github.com/cuongceg/validate_yaml/internal/config"
@@ -54,7 +55,7 @@ func MapConnectors(uc *config.UserConfig) (map[string]core.Connector, error) {
 				rmqCfg.Egresses = append(rmqCfg.Egresses, rabbitmq.EgressConfig{
 					TargetName:         eg.Name,
 					Exchange:           eg.Exchange,
-					RoutingKey:         eg.RoutingKeyTemplate, // nếu là template, bạn có thể render ở tầng route
+					RoutingKey:         eg.RoutingKeyTemplate, // template, render lúc publish
 					Persistent:         true,
 					DefaultContentType: "application/x-protobuf",
 					PublishTimeout:     5 * time.Second,
@@ -104,14 +105,14 @@ func MapConnectors(uc *config.UserConfig) (map[string]core.Connector, error) {
 					//KeyFrom:    eg.KeyFrom,
 				})
 			}
-			// if c.SASL != nil && c.SASL.Enabled {
-			// 	kafkaCfg.SASL = &kafka.SASL{
-			// 		Enable:    true,
-			// 		Mechanism: c.SASL.Mechanism,
-			// 		Username:  c.SASL.Username,
-			// 		Password:  c.SASL.Password,
-			// 	}
-			// }
+			if c.SASL != nil && c.SASL.Enabled {
+				kafkaCfg.SASL = &kafka.SASL{
+					Enable:    true,
+					Mechanism: c.SASL.Mechanism,
+					Username:  c.SASL.Username,
+					Password:  c.SASL.Password,
+				}
+			}
 			if c.TLS != nil && c.TLS.Enabled {
 				kafkaCfg.TLS = &kafka.TLS{
 					Enable:   true,
@@ -132,25 +133,66 @@ func MapConnectors(uc *config.UserConfig) (map[string]core.Connector, error) {
 			conns[c.Name] = conn
 
 		case "nats":
-			_, err := decodeParams[nats.ConnectorConfig](c.Params)
+			natsCfg, err := decodeParams[nats.ConnectorConfig](c.Params)
 			if err != nil {
 				return nil, fmt.Errorf("connector %q: decode params: %w", c.Name, err)
 			}
-			// natsCfg.Name = c.Name
-			// natsCfg.Ingresses = nil
-			// for _, ig := range c.Ingress {
-			// 	natsCfg.Ingresses = append(natsCfg.Ingresses, nats.IngressConfig{
-			// 		SourceName:    ig.SourceName,
-			// 		Subjects:      []string{ig.Subject},
-			// 		HeadersToMeta: ig.HeadersToMeta,
-			// 		KeyFrom:       ig.KeyFrom,
-			// 		JS: nats.IngressJetStream{
-			// 			Enabled:       ig.JetStream.Enabled,
-			// 			Subject:       ig.JetStream.Subject,
-			// 			Durable:       ig.JetStream.Durable,
-			// 		},
-			// 	})
-			// }
+			natsCfg.Name = c.Name
+			if url, ok := c.Params["url"].(string); ok {
+				natsCfg.Servers = nil
+				for _, s := range strings.Split(url, ",") {
+					if s = strings.TrimSpace(s); s != "" {
+						natsCfg.Servers = append(natsCfg.Servers, s)
+					}
+				}
+			}
+			natsCfg.Ingresses = nil
+			for _, ig := range c.Ingress {
+				ic := nats.IngressConfig{
+					SourceName:    ig.SourceName,
+					Subjects:      []string{ig.Subject},
+					HeadersToMeta: ig.HeadersToMeta,
+					KeyFrom:       ig.KeyFrom,
+					JS: nats.IngressJetStream{
+						Queue: ig.GroupID,
+					},
+				}
+				if ig.JetStream != nil && ig.JetStream.Enabled {
+					ic.JS.Enabled = true
+					ic.JS.Subject = ig.Subject
+					ic.JS.Durable = ig.JetStream.Durable
+					ic.JS.PullMode = ig.JetStream.Pull
+				}
+				natsCfg.Ingresses = append(natsCfg.Ingresses, ic)
+			}
+			natsCfg.Egresses = nil
+			for _, eg := range c.Egress {
+				natsCfg.Egresses = append(natsCfg.Egresses, nats.EgressConfig{
+					TargetName: eg.Name,
+					Subject:    eg.SubjectTemplate,
+					JS: nats.EgressJetStream{
+						Enabled: eg.JetStream,
+					},
+				})
+			}
+			if c.TLS != nil && c.TLS.Enabled {
+				natsCfg.TLS = nats.TLSConfig{
+					Enabled:            true,
+					InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+					CAFile:             c.TLS.CAFile,
+					CertFile:           c.TLS.CertFile,
+					KeyFile:            c.TLS.KeyFile,
+				}
+			}
+
+			conn, err := core.BuildConnector("nats", natsCfg)
+			if err != nil {
+				return nil, fmt.Errorf("connector %q: build: %w", c.Name, err)
+			}
+			if err := conn.Open(); err != nil {
+				return nil, fmt.Errorf("connector %q: open: %w", c.Name, err)
+			}
+			conns[c.Name] = conn
 
 		default:
 			return nil, fmt.Errorf("connector %q: unsupported type %q", c.Name, c.Type)